@@ -0,0 +1,441 @@
+package buf
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// AbsoluteAccessor is implemented by ByteBufs that read and write at an
+// absolute index without moving readerIndex, writerIndex or the marks. An
+// index addresses the same position space as ReaderIndex and WriterIndex,
+// and every access must fall within [0, WriterIndex()); out-of-range
+// accesses panic with ErrInsufficientSize.
+type AbsoluteAccessor interface {
+	GetByte(idx int) byte
+	GetBytes(idx int, dst []byte) ByteBuf
+	GetInt16(idx int) int16
+	GetInt32(idx int) int32
+	GetInt64(idx int) int64
+	GetUInt16(idx int) uint16
+	GetUInt32(idx int) uint32
+	GetUInt64(idx int) uint64
+	GetFloat32(idx int) float32
+	GetFloat64(idx int) float64
+	GetInt16LE(idx int) int16
+	GetInt32LE(idx int) int32
+	GetInt64LE(idx int) int64
+	GetUInt16LE(idx int) uint16
+	GetUInt32LE(idx int) uint32
+	GetUInt64LE(idx int) uint64
+	GetFloat32LE(idx int) float32
+	GetFloat64LE(idx int) float64
+	SetByte(idx int, v byte) ByteBuf
+	SetBytes(idx int, src []byte) ByteBuf
+	SetInt16(idx int, v int16) ByteBuf
+	SetInt32(idx int, v int32) ByteBuf
+	SetInt64(idx int, v int64) ByteBuf
+	SetUInt16(idx int, v uint16) ByteBuf
+	SetUInt32(idx int, v uint32) ByteBuf
+	SetUInt64(idx int, v uint64) ByteBuf
+	SetFloat32(idx int, v float32) ByteBuf
+	SetFloat64(idx int, v float64) ByteBuf
+	SetInt16LE(idx int, v int16) ByteBuf
+	SetInt32LE(idx int, v int32) ByteBuf
+	SetInt64LE(idx int, v int64) ByteBuf
+	SetUInt16LE(idx int, v uint16) ByteBuf
+	SetUInt32LE(idx int, v uint32) ByteBuf
+	SetUInt64LE(idx int, v uint64) ByteBuf
+	SetFloat32LE(idx int, v float32) ByteBuf
+	SetFloat64LE(idx int, v float64) ByteBuf
+}
+
+var (
+	_ AbsoluteAccessor = (*DefaultByteBuf)(nil)
+	_ AbsoluteAccessor = (*defaultCompositeByteBuf)(nil)
+)
+
+// ---------- DefaultByteBuf ----------
+
+// checkIndex panics unless [idx, idx+n) lies within [0, writerIndex).
+func (b *DefaultByteBuf) checkIndex(idx, n int) {
+	if idx < 0 || n < 0 || idx > b.writerIndex-n {
+		panic(b.errInsufficient())
+	}
+}
+
+func (b *DefaultByteBuf) GetByte(idx int) byte {
+	b.checkIndex(idx, 1)
+	return b.buf[idx]
+}
+
+// GetBytes copies len(dst) bytes starting at idx into dst.
+func (b *DefaultByteBuf) GetBytes(idx int, dst []byte) ByteBuf {
+	b.checkIndex(idx, len(dst))
+	copy(dst, b.buf[idx:])
+	return b
+}
+
+func (b *DefaultByteBuf) SetByte(idx int, v byte) ByteBuf {
+	b.checkIndex(idx, 1)
+	b.buf[idx] = v
+	return b
+}
+
+// SetBytes overwrites len(src) bytes starting at idx with src.
+func (b *DefaultByteBuf) SetBytes(idx int, src []byte) ByteBuf {
+	b.checkIndex(idx, len(src))
+	copy(b.buf[idx:], src)
+	return b
+}
+
+func (b *DefaultByteBuf) GetInt16(idx int) int16 {
+	return int16(b.GetUInt16(idx))
+}
+
+func (b *DefaultByteBuf) GetInt32(idx int) int32 {
+	return int32(b.GetUInt32(idx))
+}
+
+func (b *DefaultByteBuf) GetInt64(idx int) int64 {
+	return int64(b.GetUInt64(idx))
+}
+
+func (b *DefaultByteBuf) GetUInt16(idx int) uint16 {
+	b.checkIndex(idx, 2)
+	return binary.BigEndian.Uint16(b.buf[idx:])
+}
+
+func (b *DefaultByteBuf) GetUInt32(idx int) uint32 {
+	b.checkIndex(idx, 4)
+	return binary.BigEndian.Uint32(b.buf[idx:])
+}
+
+func (b *DefaultByteBuf) GetUInt64(idx int) uint64 {
+	b.checkIndex(idx, 8)
+	return binary.BigEndian.Uint64(b.buf[idx:])
+}
+
+func (b *DefaultByteBuf) GetFloat32(idx int) float32 {
+	return math.Float32frombits(b.GetUInt32(idx))
+}
+
+func (b *DefaultByteBuf) GetFloat64(idx int) float64 {
+	return math.Float64frombits(b.GetUInt64(idx))
+}
+
+func (b *DefaultByteBuf) SetInt16(idx int, v int16) ByteBuf {
+	return b.SetUInt16(idx, uint16(v))
+}
+
+func (b *DefaultByteBuf) SetInt32(idx int, v int32) ByteBuf {
+	return b.SetUInt32(idx, uint32(v))
+}
+
+func (b *DefaultByteBuf) SetInt64(idx int, v int64) ByteBuf {
+	return b.SetUInt64(idx, uint64(v))
+}
+
+func (b *DefaultByteBuf) SetUInt16(idx int, v uint16) ByteBuf {
+	b.checkIndex(idx, 2)
+	binary.BigEndian.PutUint16(b.buf[idx:], v)
+	return b
+}
+
+func (b *DefaultByteBuf) SetUInt32(idx int, v uint32) ByteBuf {
+	b.checkIndex(idx, 4)
+	binary.BigEndian.PutUint32(b.buf[idx:], v)
+	return b
+}
+
+func (b *DefaultByteBuf) SetUInt64(idx int, v uint64) ByteBuf {
+	b.checkIndex(idx, 8)
+	binary.BigEndian.PutUint64(b.buf[idx:], v)
+	return b
+}
+
+func (b *DefaultByteBuf) SetFloat32(idx int, v float32) ByteBuf {
+	return b.SetUInt32(idx, math.Float32bits(v))
+}
+
+func (b *DefaultByteBuf) SetFloat64(idx int, v float64) ByteBuf {
+	return b.SetUInt64(idx, math.Float64bits(v))
+}
+
+func (b *DefaultByteBuf) GetInt16LE(idx int) int16 {
+	return int16(b.GetUInt16LE(idx))
+}
+
+func (b *DefaultByteBuf) GetInt32LE(idx int) int32 {
+	return int32(b.GetUInt32LE(idx))
+}
+
+func (b *DefaultByteBuf) GetInt64LE(idx int) int64 {
+	return int64(b.GetUInt64LE(idx))
+}
+
+func (b *DefaultByteBuf) GetUInt16LE(idx int) uint16 {
+	b.checkIndex(idx, 2)
+	return binary.LittleEndian.Uint16(b.buf[idx:])
+}
+
+func (b *DefaultByteBuf) GetUInt32LE(idx int) uint32 {
+	b.checkIndex(idx, 4)
+	return binary.LittleEndian.Uint32(b.buf[idx:])
+}
+
+func (b *DefaultByteBuf) GetUInt64LE(idx int) uint64 {
+	b.checkIndex(idx, 8)
+	return binary.LittleEndian.Uint64(b.buf[idx:])
+}
+
+func (b *DefaultByteBuf) GetFloat32LE(idx int) float32 {
+	return math.Float32frombits(b.GetUInt32LE(idx))
+}
+
+func (b *DefaultByteBuf) GetFloat64LE(idx int) float64 {
+	return math.Float64frombits(b.GetUInt64LE(idx))
+}
+
+func (b *DefaultByteBuf) SetInt16LE(idx int, v int16) ByteBuf {
+	return b.SetUInt16LE(idx, uint16(v))
+}
+
+func (b *DefaultByteBuf) SetInt32LE(idx int, v int32) ByteBuf {
+	return b.SetUInt32LE(idx, uint32(v))
+}
+
+func (b *DefaultByteBuf) SetInt64LE(idx int, v int64) ByteBuf {
+	return b.SetUInt64LE(idx, uint64(v))
+}
+
+func (b *DefaultByteBuf) SetUInt16LE(idx int, v uint16) ByteBuf {
+	b.checkIndex(idx, 2)
+	binary.LittleEndian.PutUint16(b.buf[idx:], v)
+	return b
+}
+
+func (b *DefaultByteBuf) SetUInt32LE(idx int, v uint32) ByteBuf {
+	b.checkIndex(idx, 4)
+	binary.LittleEndian.PutUint32(b.buf[idx:], v)
+	return b
+}
+
+func (b *DefaultByteBuf) SetUInt64LE(idx int, v uint64) ByteBuf {
+	b.checkIndex(idx, 8)
+	binary.LittleEndian.PutUint64(b.buf[idx:], v)
+	return b
+}
+
+func (b *DefaultByteBuf) SetFloat32LE(idx int, v float32) ByteBuf {
+	return b.SetUInt32LE(idx, math.Float32bits(v))
+}
+
+func (b *DefaultByteBuf) SetFloat64LE(idx int, v float64) ByteBuf {
+	return b.SetUInt64LE(idx, math.Float64bits(v))
+}
+
+// ---------- defaultCompositeByteBuf ----------
+
+// checkIndex panics unless [idx, idx+n) lies within [0, writerIdx).
+func (c *defaultCompositeByteBuf) checkIndex(idx, n int) {
+	if idx < 0 || n < 0 || idx > c.writerIdx-n {
+		panic(ErrInsufficientSize)
+	}
+}
+
+// setAt writes src through the backing arrays of the components covering
+// [idx, idx+len(src)).
+func (c *defaultCompositeByteBuf) setAt(idx int, src []byte) {
+	c.checkIndex(idx, len(src))
+	written := 0
+	for written < len(src) {
+		compIdx, offIn := c.locate(idx + written)
+		written += copy(c.components[compIdx].data[offIn:], src[written:])
+	}
+}
+
+func (c *defaultCompositeByteBuf) GetByte(idx int) byte {
+	c.checkIndex(idx, 1)
+	compIdx, offIn := c.locate(idx)
+	return c.components[compIdx].data[offIn]
+}
+
+// GetBytes copies len(dst) bytes starting at idx into dst, walking
+// component boundaries as needed.
+func (c *defaultCompositeByteBuf) GetBytes(idx int, dst []byte) ByteBuf {
+	c.checkIndex(idx, len(dst))
+	c.copyAt(idx, dst)
+	return c
+}
+
+func (c *defaultCompositeByteBuf) SetByte(idx int, v byte) ByteBuf {
+	c.checkIndex(idx, 1)
+	compIdx, offIn := c.locate(idx)
+	c.components[compIdx].data[offIn] = v
+	return c
+}
+
+// SetBytes overwrites len(src) bytes starting at idx. The bytes land in
+// the components' backing arrays, so aliased sources observe the change.
+func (c *defaultCompositeByteBuf) SetBytes(idx int, src []byte) ByteBuf {
+	c.setAt(idx, src)
+	return c
+}
+
+func (c *defaultCompositeByteBuf) GetInt16(idx int) int16 {
+	return int16(c.GetUInt16(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetInt32(idx int) int32 {
+	return int32(c.GetUInt32(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetInt64(idx int) int64 {
+	return int64(c.GetUInt64(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetUInt16(idx int) uint16 {
+	var tmp [2]byte
+	c.GetBytes(idx, tmp[:])
+	return binary.BigEndian.Uint16(tmp[:])
+}
+
+func (c *defaultCompositeByteBuf) GetUInt32(idx int) uint32 {
+	var tmp [4]byte
+	c.GetBytes(idx, tmp[:])
+	return binary.BigEndian.Uint32(tmp[:])
+}
+
+func (c *defaultCompositeByteBuf) GetUInt64(idx int) uint64 {
+	var tmp [8]byte
+	c.GetBytes(idx, tmp[:])
+	return binary.BigEndian.Uint64(tmp[:])
+}
+
+func (c *defaultCompositeByteBuf) GetFloat32(idx int) float32 {
+	return math.Float32frombits(c.GetUInt32(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetFloat64(idx int) float64 {
+	return math.Float64frombits(c.GetUInt64(idx))
+}
+
+func (c *defaultCompositeByteBuf) SetInt16(idx int, v int16) ByteBuf {
+	return c.SetUInt16(idx, uint16(v))
+}
+
+func (c *defaultCompositeByteBuf) SetInt32(idx int, v int32) ByteBuf {
+	return c.SetUInt32(idx, uint32(v))
+}
+
+func (c *defaultCompositeByteBuf) SetInt64(idx int, v int64) ByteBuf {
+	return c.SetUInt64(idx, uint64(v))
+}
+
+func (c *defaultCompositeByteBuf) SetUInt16(idx int, v uint16) ByteBuf {
+	var tmp [2]byte
+	binary.BigEndian.PutUint16(tmp[:], v)
+	c.setAt(idx, tmp[:])
+	return c
+}
+
+func (c *defaultCompositeByteBuf) SetUInt32(idx int, v uint32) ByteBuf {
+	var tmp [4]byte
+	binary.BigEndian.PutUint32(tmp[:], v)
+	c.setAt(idx, tmp[:])
+	return c
+}
+
+func (c *defaultCompositeByteBuf) SetUInt64(idx int, v uint64) ByteBuf {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	c.setAt(idx, tmp[:])
+	return c
+}
+
+func (c *defaultCompositeByteBuf) SetFloat32(idx int, v float32) ByteBuf {
+	return c.SetUInt32(idx, math.Float32bits(v))
+}
+
+func (c *defaultCompositeByteBuf) SetFloat64(idx int, v float64) ByteBuf {
+	return c.SetUInt64(idx, math.Float64bits(v))
+}
+
+func (c *defaultCompositeByteBuf) GetInt16LE(idx int) int16 {
+	return int16(c.GetUInt16LE(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetInt32LE(idx int) int32 {
+	return int32(c.GetUInt32LE(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetInt64LE(idx int) int64 {
+	return int64(c.GetUInt64LE(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetUInt16LE(idx int) uint16 {
+	var tmp [2]byte
+	c.GetBytes(idx, tmp[:])
+	return binary.LittleEndian.Uint16(tmp[:])
+}
+
+func (c *defaultCompositeByteBuf) GetUInt32LE(idx int) uint32 {
+	var tmp [4]byte
+	c.GetBytes(idx, tmp[:])
+	return binary.LittleEndian.Uint32(tmp[:])
+}
+
+func (c *defaultCompositeByteBuf) GetUInt64LE(idx int) uint64 {
+	var tmp [8]byte
+	c.GetBytes(idx, tmp[:])
+	return binary.LittleEndian.Uint64(tmp[:])
+}
+
+func (c *defaultCompositeByteBuf) GetFloat32LE(idx int) float32 {
+	return math.Float32frombits(c.GetUInt32LE(idx))
+}
+
+func (c *defaultCompositeByteBuf) GetFloat64LE(idx int) float64 {
+	return math.Float64frombits(c.GetUInt64LE(idx))
+}
+
+func (c *defaultCompositeByteBuf) SetInt16LE(idx int, v int16) ByteBuf {
+	return c.SetUInt16LE(idx, uint16(v))
+}
+
+func (c *defaultCompositeByteBuf) SetInt32LE(idx int, v int32) ByteBuf {
+	return c.SetUInt32LE(idx, uint32(v))
+}
+
+func (c *defaultCompositeByteBuf) SetInt64LE(idx int, v int64) ByteBuf {
+	return c.SetUInt64LE(idx, uint64(v))
+}
+
+func (c *defaultCompositeByteBuf) SetUInt16LE(idx int, v uint16) ByteBuf {
+	var tmp [2]byte
+	binary.LittleEndian.PutUint16(tmp[:], v)
+	c.setAt(idx, tmp[:])
+	return c
+}
+
+func (c *defaultCompositeByteBuf) SetUInt32LE(idx int, v uint32) ByteBuf {
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], v)
+	c.setAt(idx, tmp[:])
+	return c
+}
+
+func (c *defaultCompositeByteBuf) SetUInt64LE(idx int, v uint64) ByteBuf {
+	var tmp [8]byte
+	binary.LittleEndian.PutUint64(tmp[:], v)
+	c.setAt(idx, tmp[:])
+	return c
+}
+
+func (c *defaultCompositeByteBuf) SetFloat32LE(idx int, v float32) ByteBuf {
+	return c.SetUInt32LE(idx, math.Float32bits(v))
+}
+
+func (c *defaultCompositeByteBuf) SetFloat64LE(idx int, v float64) ByteBuf {
+	return c.SetUInt64LE(idx, math.Float64bits(v))
+}
@@ -0,0 +1,83 @@
+package buf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Get reads at an absolute index and leaves every index untouched.
+func TestAbsolute_Get_DoesNotMoveIndices(t *testing.T) {
+	b := EmptyByteBuf().(*DefaultByteBuf)
+	b.WriteUInt16(0x0102).WriteInt32LE(-2).WriteFloat64(math.E)
+	b.Skip(2)
+	b.MarkReaderIndex()
+
+	assert.Equal(t, uint16(0x0102), b.GetUInt16(0))
+	assert.Equal(t, int32(-2), b.GetInt32LE(2))
+	assert.Equal(t, math.E, b.GetFloat64(6))
+	assert.Equal(t, byte(0x02), b.GetByte(1))
+	dst := make([]byte, 2)
+	b.GetBytes(0, dst)
+	assert.Equal(t, []byte{0x01, 0x02}, dst)
+
+	assert.Equal(t, 2, b.ReaderIndex())
+	assert.Equal(t, 14, b.WriterIndex())
+	b.ResetReaderIndex()
+	assert.Equal(t, 2, b.ReaderIndex())
+}
+
+// Set back-patches a field in place, e.g. a length prefix.
+func TestAbsolute_Set_BackPatch(t *testing.T) {
+	b := EmptyByteBuf().(*DefaultByteBuf)
+	b.WriteUInt32(0)
+	b.WriteString("payload")
+	b.SetUInt32(0, uint32(b.ReadableBytes()-4))
+	assert.Equal(t, uint32(7), b.ReadUInt32())
+	assert.Equal(t, "payload", string(b.Bytes()))
+
+	b.SetBytes(4, []byte("PAY"))
+	b.SetByte(10, 'D')
+	assert.Equal(t, "PAYloaD", string(b.Bytes()))
+
+	b.SetInt64LE(0, math.MinInt64)
+	assert.Equal(t, int64(math.MinInt64), b.GetInt64LE(0))
+	b.SetFloat32(0, 1.5)
+	assert.Equal(t, float32(1.5), b.GetFloat32(0))
+}
+
+// Accesses beyond writerIndex or at a negative index panic.
+func TestAbsolute_OutOfRange_Panics(t *testing.T) {
+	b := NewByteBuf([]byte{1, 2, 3}).(*DefaultByteBuf)
+	assert.Panics(t, func() { b.GetUInt32(0) })
+	assert.Panics(t, func() { b.GetByte(-1) })
+	assert.Panics(t, func() { b.SetUInt16(2, 1) })
+	assert.Panics(t, func() { b.GetBytes(1, make([]byte, 3)) })
+
+	c := asDefault(NewCompositeByteBuf(bb("ab"), bb("c")))
+	assert.Panics(t, func() { c.GetUInt32(0) })
+	assert.Panics(t, func() { c.SetByte(3, 0) })
+}
+
+// Composite Get/Set cross component boundaries and write through to the
+// aliased sources.
+func TestAbsolute_Composite_CrossBoundary(t *testing.T) {
+	a := []byte{0x00, 0x11}
+	b := []byte{0x22, 0x33, 0x44}
+	c := asDefault(NewCompositeByteBuf(bbBytes(a), bbBytes(b)))
+
+	assert.Equal(t, uint32(0x00112233), c.GetUInt32(0))
+	assert.Equal(t, uint16(0x3322), c.GetUInt16LE(2))
+	assert.Equal(t, byte(0x44), c.GetByte(4))
+
+	c.SetUInt32(1, 0xAABBCCDD)
+	assert.Equal(t, []byte{0x00, 0xAA}, a)
+	assert.Equal(t, []byte{0xBB, 0xCC, 0xDD}, b)
+	assert.Equal(t, 0, c.ReaderIndex())
+	assert.Equal(t, 5, c.WriterIndex())
+	assert.Equal(t, 2, len(c.components))
+
+	c.SetInt16LE(1, -2)
+	assert.Equal(t, int16(-2), c.GetInt16LE(1))
+}
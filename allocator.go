@@ -0,0 +1,175 @@
+package buf
+
+import "sync"
+
+// Allocator creates ByteBufs and takes them back, so each service can pick
+// its memory strategy and tests can observe allocations. Composites made by
+// an allocator draw their writable tails from it as well.
+//
+// Implementations must be safe for concurrent use.
+type Allocator interface {
+	// Buffer returns an empty buffer with Cap() >= initialCap and refcount
+	// 1. It panics with ErrInsufficientSize when initialCap is negative.
+	Buffer(initialCap int) ByteBuf
+	// Composite returns an empty composite with refcount 1 whose writable
+	// tails come from this allocator. Its ReadByteBuf and Clone copies are
+	// allocated directly and need no release.
+	Composite() CompositeByteBuf
+	// Release hands back a buffer obtained from Buffer or Composite. For a
+	// composite the tails it allocated are released too and the composite
+	// is closed. The buffer must not be used afterwards, nor any view or
+	// component slice derived from it. Buffers from other allocators are
+	// ignored.
+	Release(bb ByteBuf)
+}
+
+var (
+	// Unpooled allocates every buffer directly and leaves reclamation to
+	// the garbage collector; Release is a no-op.
+	Unpooled Allocator = unpooledAllocator{}
+	// Pooled draws buffers from the size-class pools behind AcquireByteBuf
+	// and returns them with ReleaseByteBuf.
+	Pooled Allocator = pooledAllocator{}
+)
+
+type unpooledAllocator struct{}
+
+func (unpooledAllocator) Buffer(initialCap int) ByteBuf {
+	if initialCap < 0 {
+		panic(ErrInsufficientSize)
+	}
+	b := newDefaultByteBuf()
+	b.buf = make([]byte, initialCap)
+	return b
+}
+
+func (a unpooledAllocator) Composite() CompositeByteBuf { return newAllocatedComposite(a) }
+
+func (unpooledAllocator) Release(ByteBuf) {}
+
+type pooledAllocator struct{}
+
+func (pooledAllocator) Buffer(initialCap int) ByteBuf { return acquireByteBuf(initialCap, 1) }
+
+func (a pooledAllocator) Composite() CompositeByteBuf { return newAllocatedComposite(a) }
+
+func (a pooledAllocator) Release(bb ByteBuf) {
+	switch b := bb.(type) {
+	case *DefaultByteBuf:
+		ReleaseByteBuf(b)
+	case *defaultCompositeByteBuf:
+		if b.allocator() == Allocator(a) {
+			b.releaseOwned(a)
+		}
+	case OrderedByteBuf:
+		a.Release(b.Unwrap())
+	}
+}
+
+// TrackingAllocator wraps another allocator and records every buffer it
+// hands out until it is released, so tests can assert that code under test
+// releases what it allocates. Composite tails are tracked individually.
+type TrackingAllocator struct {
+	parent Allocator
+
+	mu        sync.Mutex
+	live      map[ByteBuf]struct{}
+	allocated int
+	released  int
+}
+
+var _ Allocator = (*TrackingAllocator)(nil)
+
+// NewTrackingAllocator returns a tracker over parent; a nil parent means
+// Unpooled.
+func NewTrackingAllocator(parent Allocator) *TrackingAllocator {
+	if parent == nil {
+		parent = Unpooled
+	}
+	return &TrackingAllocator{parent: parent, live: make(map[ByteBuf]struct{})}
+}
+
+func (t *TrackingAllocator) track(bb ByteBuf) {
+	t.mu.Lock()
+	t.live[bb] = struct{}{}
+	t.allocated++
+	t.mu.Unlock()
+}
+
+// Buffer allocates from the parent and records the buffer.
+func (t *TrackingAllocator) Buffer(initialCap int) ByteBuf {
+	bb := t.parent.Buffer(initialCap)
+	t.track(bb)
+	return bb
+}
+
+// Composite records a composite whose tails are allocated, and tracked,
+// through t.
+func (t *TrackingAllocator) Composite() CompositeByteBuf {
+	c := newAllocatedComposite(t)
+	t.track(c)
+	return c
+}
+
+// Release records bb as released and hands it to the parent. Buffers t
+// did not hand out, or already got back, are ignored.
+func (t *TrackingAllocator) Release(bb ByteBuf) {
+	if o, ok := bb.(OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	t.mu.Lock()
+	_, ok := t.live[bb]
+	if ok {
+		delete(t.live, bb)
+		t.released++
+	}
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+	if c, isComposite := bb.(*defaultCompositeByteBuf); isComposite {
+		c.releaseOwned(t)
+		return
+	}
+	t.parent.Release(bb)
+}
+
+// Allocated returns the number of buffers handed out so far.
+func (t *TrackingAllocator) Allocated() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.allocated
+}
+
+// Released returns the number of buffers released so far.
+func (t *TrackingAllocator) Released() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.released
+}
+
+// Outstanding returns the number of buffers handed out and not yet
+// released.
+func (t *TrackingAllocator) Outstanding() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.live)
+}
+
+// compositeFrom returns an empty composite from a, or an allocator-less one
+// when a is nil.
+func compositeFrom(a Allocator) CompositeByteBuf {
+	if a == nil {
+		return NewCompositeByteBuf()
+	}
+	return a.Composite()
+}
+
+// releaseComposite hands c back to a, or closes it when a is nil.
+func releaseComposite(a Allocator, c CompositeByteBuf) {
+	if a == nil {
+		c.Close()
+		return
+	}
+	a.Release(c)
+}
@@ -0,0 +1,149 @@
+package buf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocator_UnpooledAndPooled(t *testing.T) {
+	for _, a := range []Allocator{Unpooled, Pooled} {
+		b := a.Buffer(100)
+		assert.GreaterOrEqual(t, b.Cap(), 100)
+		assert.Equal(t, 0, b.ReadableBytes())
+		assert.Equal(t, int32(1), b.(RefCounted).RefCnt())
+		b.WriteString("abc")
+		a.Release(b)
+
+		c := a.Composite()
+		c.AddComponent(bb("head")).WriteString("-tail")
+		assert.Equal(t, "head-tail", string(c.BytesCopy()))
+		clone := c.Clone()
+		a.Release(c)
+		assert.Equal(t, "head-tail", string(clone.BytesCopy()))
+		a.Release(clone)
+
+		assert.PanicsWithValue(t, ErrInsufficientSize, func() { a.Buffer(-1) })
+	}
+}
+
+// Pooled.Release closes only the composites Pooled handed out, and drops
+// their refcount to 0.
+func TestPooledAllocator_ReleaseComposite(t *testing.T) {
+	foreign := Unpooled.Composite()
+	foreign.WriteString("unpooled")
+	for _, c := range []CompositeByteBuf{NewCompositeByteBuf(bb("direct")), foreign} {
+		want := string(c.BytesCopy())
+		Pooled.Release(c)
+		assert.Equal(t, int32(1), c.(RefCounted).RefCnt())
+		assert.Equal(t, want, string(c.BytesCopy()))
+	}
+
+	c := Pooled.Composite()
+	c.WriteString("pooled")
+	Pooled.Release(c)
+	assert.Equal(t, int32(0), c.(RefCounted).RefCnt())
+	assert.Equal(t, 0, c.ReadableBytes())
+	assert.PanicsWithValue(t, ErrRefCountUnderflow, func() { c.(RefCounted).Release() })
+}
+
+func TestTrackingAllocator_Counts(t *testing.T) {
+	ta := NewTrackingAllocator(Pooled)
+	b1 := ta.Buffer(8)
+	b2 := ta.Buffer(8)
+	assert.Equal(t, 2, ta.Allocated())
+	assert.Equal(t, 2, ta.Outstanding())
+
+	ta.Release(b1)
+	ta.Release(b1)
+	ta.Release(EmptyByteBuf())
+	assert.Equal(t, 1, ta.Released())
+	assert.Equal(t, 1, ta.Outstanding())
+
+	ta.Release(Order(b2, nil))
+	assert.Equal(t, 0, ta.Outstanding())
+	assert.Equal(t, 2, ta.Released())
+}
+
+// A composite's writable tails are allocated, and released, through the
+// tracker along with the composite itself.
+func TestTrackingAllocator_CompositeTails(t *testing.T) {
+	ta := NewTrackingAllocator(nil)
+	c := ta.Composite()
+	c.WriteString("one")
+	c.AddComponent(bb("two"))
+	c.WriteString("three")
+	assert.Equal(t, "onetwothree", string(c.BytesCopy()))
+	assert.Equal(t, 3, ta.Outstanding())
+
+	// Copies are unpooled and need no release.
+	copied := c.ReadByteBuf(6)
+	assert.Equal(t, "onetwo", string(copied.BytesCopy()))
+	assert.Equal(t, "three", string(c.Clone().BytesCopy()))
+	assert.Equal(t, 3, ta.Outstanding())
+
+	ta.Release(c)
+	assert.Equal(t, 0, ta.Outstanding())
+	assert.Equal(t, 3, ta.Released())
+
+	// Reset hands the tails back and keeps the composite usable.
+	c = ta.Composite()
+	c.WriteString("four")
+	c.AddComponent(bb("five"))
+	c.WriteString("six")
+	assert.Equal(t, 3, ta.Outstanding())
+	c.Reset()
+	assert.Equal(t, 1, ta.Outstanding())
+	c.WriteString("seven")
+	assert.Equal(t, "seven", string(c.BytesCopy()))
+	ta.Release(c)
+	assert.Equal(t, 0, ta.Outstanding())
+}
+
+func TestAllocator_Codecs(t *testing.T) {
+	ta := NewTrackingAllocator(nil)
+
+	p := NewLengthFieldPrepender(LengthFieldPrependerConfig{LengthFieldLength: 2, Allocator: ta})
+	out, err := p.Encode(bb("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "\x00\x05hello", string(out.BytesCopy()))
+	assert.NotZero(t, ta.Outstanding())
+
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{MaxFrameLength: 64, LengthFieldLength: 2, Allocator: ta})
+	frames, err := d.Decode(out)
+	assert.NoError(t, err)
+	assert.Len(t, frames, 1)
+	ta.Release(out)
+
+	lines := NewDelimiterFrameDecoder(DelimiterConfig{MaxFrameLength: 64, Delimiters: LineDelimiters(), Allocator: ta})
+	frames, err = lines.Decode(bb("a\nb\n"))
+	assert.NoError(t, err)
+	assert.Len(t, frames, 2)
+
+	rd := NewReplayingDecoder(decodeReplayMsg, ta)
+	msgs, err := rd.Decode(bb("\x01\x00\x02hi"))
+	assert.NoError(t, err)
+	assert.Equal(t, []replayMsg{{1, "hi"}}, msgs)
+	// The decoders' cumulations are the only buffers left.
+	assert.Equal(t, 3, ta.Outstanding())
+	assert.NoError(t, rd.Close())
+	assert.Equal(t, 2, ta.Outstanding())
+
+	ca := NewTrackingAllocator(Pooled)
+	comp, err := NewCompressor(CodecGzip, -1)
+	assert.NoError(t, err)
+	comp.SetAllocator(ca)
+	compressed, err := comp.Compress(bbBytes(compressInput))
+	assert.NoError(t, err)
+	assert.NoError(t, comp.Finish(compressed))
+	dec, err := NewDecompressor(CodecGzip, 0)
+	assert.NoError(t, err)
+	dec.SetAllocator(ca)
+	plain, err := dec.Decompress(compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, compressInput, plain.Bytes())
+	assert.Equal(t, 2, ca.Outstanding())
+	ca.Release(compressed)
+	ca.Release(plain)
+	assert.Equal(t, 0, ca.Outstanding())
+}
@@ -0,0 +1,311 @@
+package buf
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// ErrInvalidBitCount is raised when a bit field width is outside 1..64.
+var ErrInvalidBitCount = errors.New("invalid bit count")
+
+// ErrExpGolombOverflow is returned when an Exp-Golomb code does not fit in
+// 64 bits.
+var ErrExpGolombOverflow = errors.New("exp-golomb code overflows 64 bits")
+
+// BitOrder selects how bit fields are packed into bytes.
+type BitOrder int
+
+const (
+	// MSBFirst fills each byte from its most significant bit down and
+	// stores a field's most significant bit first, as in H.264 and most
+	// network headers.
+	MSBFirst BitOrder = iota
+	// LSBFirst fills each byte from its least significant bit up and
+	// stores a field's least significant bit first, as in deflate.
+	LSBFirst
+)
+
+func checkBitCount(n int) {
+	if n < 1 || n > 64 {
+		panic(ErrInvalidBitCount)
+	}
+}
+
+func bitMask(n int) uint64 {
+	return 1<<uint(n) - 1
+}
+
+// BitReader reads bit fields from a ByteBuf. Whole bytes are taken from the
+// buffer with MustReadByte as soon as their first bit is needed, so the
+// wrapped buffer's readerIndex always points past the partially consumed
+// byte. Call Align before switching back to the byte-level readers.
+//
+// BitReader is NOT goroutine-safe.
+type BitReader struct {
+	bb    ByteBuf
+	order BitOrder
+	cur   byte // unread bits: the low nbits for MSBFirst, shifted down for LSBFirst
+	nbits int
+}
+
+// NewBitReader returns a BitReader over bb's readable region.
+func NewBitReader(bb ByteBuf, order BitOrder) *BitReader {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	return &BitReader{bb: bb, order: order}
+}
+
+// Order returns the bit order r was created with.
+func (r *BitReader) Order() BitOrder { return r.order }
+
+// ReadableBits returns the number of bits left: the unread bits of the
+// current byte plus the readable bytes of the wrapped buffer.
+func (r *BitReader) ReadableBits() int {
+	return r.nbits + 8*r.bb.ReadableBytes()
+}
+
+// IsAligned reports whether the reader sits on a byte boundary.
+func (r *BitReader) IsAligned() bool { return r.nbits == 0 }
+
+// Align discards the unread bits of the current byte.
+func (r *BitReader) Align() *BitReader {
+	r.cur, r.nbits = 0, 0
+	return r
+}
+
+// ReadBit reads a single bit.
+func (r *BitReader) ReadBit() (bool, error) {
+	v, err := r.ReadBits(1)
+	return v == 1, err
+}
+
+// ReadBits reads an n-bit unsigned value, 1 <= n <= 64. It returns
+// ErrInsufficientSize without consuming anything when fewer than n bits
+// remain.
+func (r *BitReader) ReadBits(n int) (uint64, error) {
+	checkBitCount(n)
+	if r.ReadableBits() < n {
+		return 0, ErrInsufficientSize
+	}
+	return r.readBits(n), nil
+}
+
+// ReadSignedBits reads an n-bit two's complement value and sign-extends it.
+func (r *BitReader) ReadSignedBits(n int) (int64, error) {
+	v, err := r.ReadBits(n)
+	if err != nil {
+		return 0, err
+	}
+	shift := uint(64 - n)
+	return int64(v<<shift) >> shift, nil
+}
+
+// ReadUE reads an unsigned Exp-Golomb code, ue(v) in H.264 terms. It
+// returns ErrInsufficientSize without consuming anything on truncated
+// input.
+func (r *BitReader) ReadUE() (uint64, error) {
+	zeros := 0
+	for {
+		bit, ok := r.peekBit(zeros)
+		if !ok {
+			return 0, ErrInsufficientSize
+		}
+		if bit {
+			break
+		}
+		zeros++
+		if zeros > 63 {
+			return 0, ErrExpGolombOverflow
+		}
+	}
+	if r.ReadableBits() < 2*zeros+1 {
+		return 0, ErrInsufficientSize
+	}
+	r.readBits(zeros + 1)
+	if zeros == 0 {
+		return 0, nil
+	}
+	return bitMask(zeros) + r.readBits(zeros), nil
+}
+
+// ReadSE reads a signed Exp-Golomb code, se(v) in H.264 terms.
+func (r *BitReader) ReadSE() (int64, error) {
+	v, err := r.ReadUE()
+	if err != nil {
+		return 0, err
+	}
+	if v&1 == 1 {
+		return int64(v>>1) + 1, nil
+	}
+	return -int64(v >> 1), nil
+}
+
+func (r *BitReader) readBits(n int) uint64 {
+	var v uint64
+	for got := 0; got < n; {
+		if r.nbits == 0 {
+			r.cur, r.nbits = r.bb.MustReadByte(), 8
+		}
+		k := min(n-got, r.nbits)
+		if r.order == MSBFirst {
+			v = v<<uint(k) | uint64(r.cur>>uint(r.nbits-k))&bitMask(k)
+		} else {
+			v |= (uint64(r.cur) & bitMask(k)) << uint(got)
+			r.cur >>= uint(k)
+		}
+		r.nbits -= k
+		got += k
+	}
+	return v
+}
+
+// peekBit returns the i-th upcoming bit without consuming it.
+func (r *BitReader) peekBit(i int) (bit, ok bool) {
+	if i < r.nbits {
+		if r.order == MSBFirst {
+			return r.cur>>uint(r.nbits-1-i)&1 == 1, true
+		}
+		return r.cur>>uint(i)&1 == 1, true
+	}
+	i -= r.nbits
+	if i/8 >= r.bb.ReadableBytes() {
+		return false, false
+	}
+	b := r.peekByte(i / 8)
+	if r.order == MSBFirst {
+		return b>>uint(7-i%8)&1 == 1, true
+	}
+	return b>>uint(i%8)&1 == 1, true
+}
+
+// peekByte returns the readable byte at offset i without moving indices.
+func (r *BitReader) peekByte(i int) byte {
+	if aa, ok := r.bb.(AbsoluteAccessor); ok {
+		return aa.GetByte(r.bb.ReaderIndex() + i)
+	}
+	return r.bb.Bytes()[i]
+}
+
+// BitWriter writes bit fields to a ByteBuf. Every completed byte is
+// appended to the buffer immediately; a partial byte is held until Align
+// pads it with zero bits, after which the byte-level writers can be used
+// again.
+//
+// BitWriter is NOT goroutine-safe.
+type BitWriter struct {
+	bb    ByteBuf
+	order BitOrder
+	cur   byte
+	nbits int
+}
+
+// NewBitWriter returns a BitWriter appending to bb.
+func NewBitWriter(bb ByteBuf, order BitOrder) *BitWriter {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	return &BitWriter{bb: bb, order: order}
+}
+
+// Order returns the bit order w was created with.
+func (w *BitWriter) Order() BitOrder { return w.order }
+
+// Buffered returns the number of bits held in the partial byte.
+func (w *BitWriter) Buffered() int { return w.nbits }
+
+// IsAligned reports whether the writer sits on a byte boundary.
+func (w *BitWriter) IsAligned() bool { return w.nbits == 0 }
+
+// Align pads the partial byte with zero bits and appends it to the buffer.
+func (w *BitWriter) Align() *BitWriter {
+	if w.nbits > 0 {
+		w.bb.AppendByte(w.cur)
+		w.cur, w.nbits = 0, 0
+	}
+	return w
+}
+
+// WriteBit writes a single bit.
+func (w *BitWriter) WriteBit(bit bool) *BitWriter {
+	if bit {
+		w.writeBits(1, 1)
+	} else {
+		w.writeBits(0, 1)
+	}
+	return w
+}
+
+// WriteBits writes the low n bits of v, 1 <= n <= 64. It panics with
+// ErrValueOverflow when v does not fit in n bits.
+func (w *BitWriter) WriteBits(v uint64, n int) *BitWriter {
+	checkBitCount(n)
+	if n < 64 && v>>uint(n) != 0 {
+		panic(ErrValueOverflow)
+	}
+	w.writeBits(v, n)
+	return w
+}
+
+// WriteSignedBits writes v as an n-bit two's complement value. It panics
+// with ErrValueOverflow when v is outside the n-bit range.
+func (w *BitWriter) WriteSignedBits(v int64, n int) *BitWriter {
+	checkBitCount(n)
+	if n < 64 {
+		lim := int64(1) << uint(n-1)
+		if v < -lim || v >= lim {
+			panic(ErrValueOverflow)
+		}
+	}
+	w.writeBits(uint64(v)&bitMask(n), n)
+	return w
+}
+
+// WriteUE writes v as an unsigned Exp-Golomb code. It panics with
+// ErrValueOverflow for math.MaxUint64, which has no 64-bit code.
+func (w *BitWriter) WriteUE(v uint64) *BitWriter {
+	if v == 1<<64-1 {
+		panic(ErrValueOverflow)
+	}
+	x := v + 1
+	zeros := bits.Len64(x) - 1
+	if zeros > 0 {
+		w.writeBits(0, zeros)
+	}
+	w.writeBits(1, 1)
+	if zeros > 0 {
+		w.writeBits(x&bitMask(zeros), zeros)
+	}
+	return w
+}
+
+// WriteSE writes v as a signed Exp-Golomb code. It panics with
+// ErrValueOverflow for math.MinInt64.
+func (w *BitWriter) WriteSE(v int64) *BitWriter {
+	switch {
+	case v > 0:
+		return w.WriteUE(uint64(v)*2 - 1)
+	case v == -1<<63:
+		panic(ErrValueOverflow)
+	default:
+		return w.WriteUE(uint64(-v) * 2)
+	}
+}
+
+func (w *BitWriter) writeBits(v uint64, n int) {
+	for n > 0 {
+		k := min(n, 8-w.nbits)
+		if w.order == MSBFirst {
+			w.cur |= byte(v>>uint(n-k)&bitMask(k)) << uint(8-w.nbits-k)
+		} else {
+			w.cur |= byte(v&bitMask(k)) << uint(w.nbits)
+			v >>= uint(k)
+		}
+		w.nbits += k
+		n -= k
+		if w.nbits == 8 {
+			w.bb.AppendByte(w.cur)
+			w.cur, w.nbits = 0, 0
+		}
+	}
+}
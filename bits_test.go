@@ -0,0 +1,107 @@
+package buf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MSB-first packs fields from the top of each byte, LSB-first from the
+// bottom.
+func TestBitWriter_Layout(t *testing.T) {
+	b := EmptyByteBuf()
+	NewBitWriter(b, MSBFirst).WriteBits(0b101, 3).WriteBits(0b1, 1).WriteBits(0xABC, 12)
+	assert.Equal(t, []byte{0xBA, 0xBC}, b.Bytes())
+
+	b = EmptyByteBuf()
+	NewBitWriter(b, LSBFirst).WriteBits(0b101, 3).WriteBits(0b1, 1).WriteBits(0xABC, 12)
+	assert.Equal(t, []byte{0xCD, 0xAB}, b.Bytes())
+
+	b = EmptyByteBuf()
+	w := NewBitWriter(b, MSBFirst).WriteBit(true)
+	assert.Equal(t, 0, b.ReadableBytes())
+	assert.Equal(t, 1, w.Buffered())
+	w.Align()
+	assert.Equal(t, []byte{0x80}, b.Bytes())
+	assert.True(t, w.IsAligned())
+}
+
+func TestBits_RoundTrip(t *testing.T) {
+	for _, order := range []BitOrder{MSBFirst, LSBFirst} {
+		b := EmptyByteBuf()
+		w := NewBitWriter(b, order)
+		w.WriteBits(5, 3).WriteSignedBits(-3, 5).WriteBits(math.MaxUint64, 64).
+			WriteSignedBits(math.MinInt64, 64).WriteBit(true).WriteSignedBits(-1, 1).
+			WriteUE(0).WriteUE(7).WriteUE(math.MaxUint64 - 1).WriteSE(-4).WriteSE(4).Align()
+		b.WriteUInt16(0xBEEF)
+
+		r := NewBitReader(b, order)
+		v, err := r.ReadBits(3)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(5), v)
+		s, _ := r.ReadSignedBits(5)
+		assert.Equal(t, int64(-3), s)
+		v, _ = r.ReadBits(64)
+		assert.Equal(t, uint64(math.MaxUint64), v)
+		s, _ = r.ReadSignedBits(64)
+		assert.Equal(t, int64(math.MinInt64), s)
+		bit, _ := r.ReadBit()
+		assert.True(t, bit)
+		s, _ = r.ReadSignedBits(1)
+		assert.Equal(t, int64(-1), s)
+		for _, want := range []uint64{0, 7, math.MaxUint64 - 1} {
+			v, err = r.ReadUE()
+			assert.NoError(t, err)
+			assert.Equal(t, want, v)
+		}
+		for _, want := range []int64{-4, 4} {
+			s, err = r.ReadSE()
+			assert.NoError(t, err)
+			assert.Equal(t, want, s)
+		}
+		r.Align()
+		assert.Equal(t, uint16(0xBEEF), b.ReadUInt16(), "order %d", order)
+	}
+}
+
+// Bit fields mix with byte-level reads on the same buffer, including a
+// composite one.
+func TestBitReader_SharesReaderIndex(t *testing.T) {
+	c := NewCompositeByteBuf(bbBytes([]byte{0xF0}), bbBytes([]byte{0x12, 0x34}))
+	r := NewBitReader(c, MSBFirst)
+	v, _ := r.ReadBits(4)
+	assert.Equal(t, uint64(0xF), v)
+	assert.Equal(t, 1, c.ReaderIndex())
+	assert.False(t, r.IsAligned())
+	r.Align()
+	assert.Equal(t, uint16(0x1234), c.ReadUInt16())
+}
+
+// Truncated input fails without consuming bits.
+func TestBitReader_Insufficient(t *testing.T) {
+	b := bbBytes([]byte{0x00, 0x01})
+	r := NewBitReader(b, MSBFirst)
+	_, err := r.ReadBits(17)
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	_, err = r.ReadUE()
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	assert.Equal(t, 16, r.ReadableBits())
+	assert.Equal(t, 0, b.ReaderIndex())
+
+	zeros := make([]byte, 9)
+	zeros[8] = 0xFF
+	_, err = NewBitReader(bbBytes(zeros), MSBFirst).ReadUE()
+	assert.ErrorIs(t, err, ErrExpGolombOverflow)
+}
+
+func TestBitWriter_Invalid(t *testing.T) {
+	w := NewBitWriter(EmptyByteBuf(), MSBFirst)
+	assert.PanicsWithValue(t, ErrInvalidBitCount, func() { w.WriteBits(0, 0) })
+	assert.PanicsWithValue(t, ErrInvalidBitCount, func() { w.WriteBits(0, 65) })
+	assert.PanicsWithValue(t, ErrValueOverflow, func() { w.WriteBits(8, 3) })
+	assert.PanicsWithValue(t, ErrValueOverflow, func() { w.WriteSignedBits(4, 3) })
+	assert.PanicsWithValue(t, ErrValueOverflow, func() { w.WriteSignedBits(-5, 3) })
+	assert.PanicsWithValue(t, ErrValueOverflow, func() { w.WriteUE(math.MaxUint64) })
+	assert.PanicsWithValue(t, ErrValueOverflow, func() { w.WriteSE(math.MinInt64) })
+}
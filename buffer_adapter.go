@@ -0,0 +1,336 @@
+package buf
+
+import (
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// readOp records the last read so UnreadByte and UnreadRune can be checked
+// the way bytes.Buffer checks them.
+type readOp int8
+
+const (
+	opRead      readOp = -1 // any other read
+	opInvalid   readOp = 0  // non-read operation
+	opReadRune1 readOp = 1  // ReadRune of a rune of size 1
+)
+
+// BufferAdapter exposes the method set and semantics of bytes.Buffer on top
+// of a ByteBuf, so code written against bytes.Buffer can switch to pooled
+// or composite storage without renaming calls such as ReadBytes(delim),
+// which clash with ByteBuf's own methods. A typical pooled use is
+//
+//	bb := AcquireByteBuf(4096)
+//	defer ReleaseByteBuf(bb)
+//	w := NewBufferAdapter(bb)
+//
+// The zero value is an empty adapter over a fresh DefaultByteBuf. Slices
+// returned by Bytes, Next and AvailableBuffer alias the storage of the
+// wrapped buffer and are valid only until its next modification.
+//
+// BufferAdapter is NOT goroutine-safe.
+type BufferAdapter struct {
+	bb       ByteBuf
+	lastRead readOp
+}
+
+var (
+	_ io.ReadWriter   = (*BufferAdapter)(nil)
+	_ io.ReaderFrom   = (*BufferAdapter)(nil)
+	_ io.WriterTo     = (*BufferAdapter)(nil)
+	_ io.ByteScanner  = (*BufferAdapter)(nil)
+	_ io.RuneScanner  = (*BufferAdapter)(nil)
+	_ io.ByteWriter   = (*BufferAdapter)(nil)
+	_ io.StringWriter = (*BufferAdapter)(nil)
+)
+
+// NewBufferAdapter returns an adapter over bb. Reads consume bb's readable
+// region and writes append to it.
+func NewBufferAdapter(bb ByteBuf) *BufferAdapter {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	return &BufferAdapter{bb: bb}
+}
+
+// ByteBuf returns the wrapped buffer.
+func (a *BufferAdapter) ByteBuf() ByteBuf {
+	if a.bb == nil {
+		a.bb = EmptyByteBuf()
+	}
+	return a.bb
+}
+
+// Bytes returns the unread portion of the buffer. A composite is
+// consolidated into one contiguous slice.
+func (a *BufferAdapter) Bytes() []byte { return a.ByteBuf().Bytes() }
+
+// String returns the unread portion of the buffer as a string, or "<nil>"
+// for a nil adapter.
+func (a *BufferAdapter) String() string {
+	if a == nil {
+		return "<nil>"
+	}
+	bb := a.ByteBuf()
+	return string(firstBytes(bb, bb.ReadableBytes()))
+}
+
+// Len returns the number of unread bytes.
+func (a *BufferAdapter) Len() int { return a.ByteBuf().ReadableBytes() }
+
+// Cap returns the capacity of the wrapped buffer.
+func (a *BufferAdapter) Cap() int { return a.ByteBuf().Cap() }
+
+// Available returns how many bytes can be written without growing.
+func (a *BufferAdapter) Available() int {
+	bb := a.ByteBuf()
+	return bb.Cap() - bb.WriterIndex()
+}
+
+// AvailableBuffer returns an empty slice with Available() capacity over
+// the writable space of a *DefaultByteBuf, meant to be appended to and
+// passed to the next Write. Other buffers get a nil slice.
+func (a *BufferAdapter) AvailableBuffer() []byte {
+	if b, ok := a.ByteBuf().(*DefaultByteBuf); ok {
+		return b.buf[b.writerIndex:b.writerIndex]
+	}
+	return nil
+}
+
+// Truncate discards all but the first n unread bytes. It panics with
+// ErrInsufficientSize when n is negative or greater than Len.
+func (a *BufferAdapter) Truncate(n int) {
+	if n == 0 {
+		a.Reset()
+		return
+	}
+	a.lastRead = opInvalid
+	bb := a.ByteBuf()
+	if n < 0 || n > bb.ReadableBytes() {
+		panic(ErrInsufficientSize)
+	}
+	if n == bb.ReadableBytes() {
+		return
+	}
+	if b, ok := bb.(*DefaultByteBuf); ok {
+		b.writerIndex = b.readerIndex + n
+		return
+	}
+	kept := bb.ReadBytes(n)
+	bb.Reset()
+	bb.WriteBytes(kept)
+}
+
+// Reset empties the buffer but keeps its storage for future writes.
+func (a *BufferAdapter) Reset() {
+	a.ByteBuf().Reset()
+	a.lastRead = opInvalid
+}
+
+// Grow grows the buffer so another n bytes can be written without a
+// further allocation. It panics with ErrInsufficientSize when n is
+// negative.
+func (a *BufferAdapter) Grow(n int) {
+	if n < 0 {
+		panic(ErrInsufficientSize)
+	}
+	a.ByteBuf().EnsureCapacity(n)
+	a.lastRead = opInvalid
+}
+
+// Write appends p to the buffer. The error is always nil.
+func (a *BufferAdapter) Write(p []byte) (int, error) {
+	a.lastRead = opInvalid
+	return a.ByteBuf().Write(p)
+}
+
+// WriteString appends s to the buffer. The error is always nil.
+func (a *BufferAdapter) WriteString(s string) (int, error) {
+	a.lastRead = opInvalid
+	a.ByteBuf().WriteString(s)
+	return len(s), nil
+}
+
+// WriteByte appends c to the buffer. The error is always nil.
+func (a *BufferAdapter) WriteByte(c byte) error {
+	a.lastRead = opInvalid
+	a.ByteBuf().AppendByte(c)
+	return nil
+}
+
+// WriteRune appends the UTF-8 encoding of r and returns its length. The
+// error is always nil.
+func (a *BufferAdapter) WriteRune(r rune) (int, error) {
+	a.lastRead = opInvalid
+	var p [utf8.UTFMax]byte
+	n := utf8.EncodeRune(p[:], r)
+	a.ByteBuf().WriteBytes(p[:n])
+	return n, nil
+}
+
+// ReadFrom appends data from r until io.EOF, which is not reported.
+func (a *BufferAdapter) ReadFrom(r io.Reader) (int64, error) {
+	a.lastRead = opInvalid
+	bb := a.ByteBuf()
+	if rf, ok := bb.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{bb}, r)
+}
+
+// WriteTo writes the unread bytes to w until the buffer is drained or an
+// error occurs.
+func (a *BufferAdapter) WriteTo(w io.Writer) (int64, error) {
+	a.lastRead = opInvalid
+	bb := a.ByteBuf()
+	if bb.ReadableBytes() == 0 {
+		bb.Reset()
+		return 0, nil
+	}
+	var n int64
+	var err error
+	if wt, ok := bb.(io.WriterTo); ok {
+		n, err = wt.WriteTo(w)
+	} else {
+		n, err = io.Copy(w, struct{ io.Reader }{bb})
+	}
+	if err == nil {
+		bb.Reset()
+	}
+	return n, err
+}
+
+// Read reads up to len(p) unread bytes into p. It returns io.EOF when the
+// buffer is empty and p is not.
+func (a *BufferAdapter) Read(p []byte) (int, error) {
+	a.lastRead = opInvalid
+	bb := a.ByteBuf()
+	if bb.ReadableBytes() == 0 {
+		a.Reset()
+		if len(p) == 0 {
+			return 0, nil
+		}
+		return 0, io.EOF
+	}
+	n, err := bb.Read(p)
+	if n > 0 {
+		a.lastRead = opRead
+	}
+	return n, err
+}
+
+// Next returns the next n unread bytes, or all of them when fewer remain,
+// and advances past them. The slice aliases the buffer when the bytes are
+// contiguous and is a copy otherwise.
+func (a *BufferAdapter) Next(n int) []byte {
+	a.lastRead = opInvalid
+	bb := a.ByteBuf()
+	n = min(n, bb.ReadableBytes())
+	data := firstBytes(bb, n)
+	bb.Skip(n)
+	if n > 0 {
+		a.lastRead = opRead
+	}
+	return data
+}
+
+// ReadByte reads the next byte, returning io.EOF when the buffer is empty.
+func (a *BufferAdapter) ReadByte() (byte, error) {
+	bb := a.ByteBuf()
+	if bb.ReadableBytes() == 0 {
+		a.Reset()
+		return 0, io.EOF
+	}
+	a.lastRead = opRead
+	return bb.MustReadByte(), nil
+}
+
+// ReadRune reads the next UTF-8 encoded rune. Invalid encodings yield
+// utf8.RuneError with size 1. It returns io.EOF when the buffer is empty.
+func (a *BufferAdapter) ReadRune() (rune, int, error) {
+	bb := a.ByteBuf()
+	if bb.ReadableBytes() == 0 {
+		a.Reset()
+		return 0, 0, io.EOF
+	}
+	var r rune
+	var size int
+	if rr, ok := bb.(io.RuneReader); ok {
+		r, size, _ = rr.ReadRune()
+	} else {
+		r, size = decodeRune(firstBytes(bb, min(utf8.UTFMax, bb.ReadableBytes())))
+		bb.Skip(size)
+	}
+	a.lastRead = opReadRune1 + readOp(size-1)
+	return r, size, nil
+}
+
+// UnreadRune unreads the rune returned by the last ReadRune. It returns
+// ErrInvalidUnread when the last operation was not a successful ReadRune.
+func (a *BufferAdapter) UnreadRune() error {
+	if a.lastRead < opReadRune1 {
+		return ErrInvalidUnread
+	}
+	a.rewind(int(a.lastRead))
+	a.lastRead = opInvalid
+	return nil
+}
+
+// UnreadByte unreads the last byte returned by the most recent successful
+// read. It returns ErrInvalidUnread when the last operation was not one.
+func (a *BufferAdapter) UnreadByte() error {
+	if a.lastRead == opInvalid {
+		return ErrInvalidUnread
+	}
+	a.lastRead = opInvalid
+	if a.ByteBuf().ReaderIndex() > 0 {
+		a.rewind(1)
+	}
+	return nil
+}
+
+// rewind moves the reader index of the wrapped buffer back n bytes. It
+// panics with ErrUnsupportedOperation when the buffer is not an
+// io.ByteScanner.
+func (a *BufferAdapter) rewind(n int) {
+	s, ok := a.ByteBuf().(io.ByteScanner)
+	if !ok {
+		panic(ErrUnsupportedOperation)
+	}
+	for range n {
+		if err := s.UnreadByte(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// ReadBytes reads up to and including the first occurrence of delim and
+// returns a copy of the bytes read. When delim is not found it returns
+// the rest of the buffer and io.EOF.
+func (a *BufferAdapter) ReadBytes(delim byte) ([]byte, error) {
+	line, err := a.readSlice(delim)
+	return bytes.Clone(line), err
+}
+
+// ReadString is ReadBytes returning a string.
+func (a *BufferAdapter) ReadString(delim byte) (string, error) {
+	line, err := a.readSlice(delim)
+	return string(line), err
+}
+
+// readSlice consumes up to and including delim and returns the consumed
+// bytes, aliasing the buffer when they are contiguous.
+func (a *BufferAdapter) readSlice(delim byte) ([]byte, error) {
+	bb := a.ByteBuf()
+	var err error
+	n := indexOf(bb, 0, []byte{delim}) + 1
+	if n == 0 {
+		n = bb.ReadableBytes()
+		err = io.EOF
+	}
+	line := firstBytes(bb, n)
+	bb.Skip(n)
+	a.lastRead = opRead
+	return line, err
+}
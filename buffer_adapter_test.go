@@ -0,0 +1,156 @@
+package buf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// bufferLike is the bytes.Buffer method set shared with BufferAdapter.
+type bufferLike interface {
+	Bytes() []byte
+	String() string
+	Len() int
+	Truncate(n int)
+	Reset()
+	Write(p []byte) (int, error)
+	WriteString(s string) (int, error)
+	WriteByte(c byte) error
+	WriteRune(r rune) (int, error)
+	Read(p []byte) (int, error)
+	Next(n int) []byte
+	ReadByte() (byte, error)
+	ReadRune() (rune, int, error)
+	UnreadRune() error
+	UnreadByte() error
+	ReadBytes(delim byte) ([]byte, error)
+	ReadString(delim byte) (string, error)
+}
+
+// The same call sequence produces the same results on bytes.Buffer and on
+// adapters over both buffer types.
+func TestBufferAdapter_MatchesBytesBuffer(t *testing.T) {
+	script := func(b bufferLike) []string {
+		var out []string
+		rec := func(v ...any) {
+			// Unread errors differ only in their text.
+			for i, x := range v {
+				if err, ok := x.(error); ok && err != io.EOF {
+					v[i] = "unread error"
+				}
+			}
+			out = append(out, fmt.Sprint(v...))
+		}
+		b.WriteString("héllo\nwörld\n")
+		b.WriteByte('!')
+		b.WriteRune('😀')
+		b.Write([]byte("tail"))
+		rec(b.Len(), b.String())
+		rec(b.ReadString('\n'))
+		rec(b.UnreadByte())
+		rec(b.ReadByte())
+		rec(b.UnreadRune())
+		rec(b.ReadRune())
+		rec(b.ReadRune())
+		rec(b.UnreadRune())
+		rec(b.UnreadRune())
+		rec(b.ReadRune())
+		rec(string(b.Next(3)))
+		rec(b.UnreadByte())
+		rec(b.ReadBytes('\n'))
+		rec(string(b.Bytes()))
+		b.Truncate(3)
+		rec(b.Len(), b.String())
+		rec(b.UnreadByte())
+		p := make([]byte, 2)
+		rec(b.Read(p))
+		rec(string(p))
+		rec(b.ReadBytes('x'))
+		rec(b.Read(p))
+		rec(b.Read(nil))
+		rec(b.ReadByte())
+		rec(b.ReadRune())
+		rec(b.UnreadByte())
+		rec(b.ReadString('\n'))
+		rec(b.Len(), b.String())
+		b.WriteString("again")
+		rec(string(b.Next(10)))
+		rec(string(b.Next(1)))
+		b.WriteString("reset")
+		b.Reset()
+		rec(b.Len(), b.String())
+		return out
+	}
+
+	want := script(new(bytes.Buffer))
+	assert.Equal(t, want, script(new(BufferAdapter)))
+	assert.Equal(t, want, script(NewBufferAdapter(AcquireByteBuf(8))))
+	assert.Equal(t, want, script(NewBufferAdapter(NewCompositeByteBuf(bb("x")).Skip(1))))
+}
+
+func TestBufferAdapter_Composite(t *testing.T) {
+	c := NewCompositeByteBuf(bb("ab\ncd"), bb("e\nf"))
+	a := NewBufferAdapter(c)
+	line, err := a.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "ab\n", line)
+	// The delimiter sits in the second component.
+	line, err = a.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "cde\n", line)
+	assert.Equal(t, "f", a.String())
+
+	a.WriteString("gh")
+	a.Truncate(2)
+	assert.Equal(t, "fg", a.String())
+	assert.Equal(t, "fg", string(c.BytesCopy()))
+}
+
+func TestBufferAdapter_AvailableBuffer(t *testing.T) {
+	bb := AcquireByteBuf(64)
+	defer ReleaseByteBuf(bb)
+	a := NewBufferAdapter(bb)
+	a.WriteString("n=")
+	assert.Equal(t, a.Cap()-2, a.Available())
+	b := a.AvailableBuffer()
+	assert.Equal(t, 0, len(b))
+	assert.Equal(t, a.Available(), cap(b))
+	b = fmt.Appendf(b, "%d", 42)
+	a.Write(b)
+	assert.Equal(t, "n=42", a.String())
+
+	assert.Nil(t, NewBufferAdapter(NewCompositeByteBuf()).AvailableBuffer())
+}
+
+func TestBufferAdapter_GrowTruncatePanics(t *testing.T) {
+	a := new(BufferAdapter)
+	a.Grow(100)
+	assert.GreaterOrEqual(t, a.Available(), 100)
+	a.WriteString("abc")
+	assert.PanicsWithValue(t, ErrInsufficientSize, func() { a.Truncate(4) })
+	assert.PanicsWithValue(t, ErrInsufficientSize, func() { a.Truncate(-1) })
+	assert.PanicsWithValue(t, ErrInsufficientSize, func() { a.Grow(-1) })
+	assert.PanicsWithValue(t, ErrNilObject, func() { NewBufferAdapter(nil) })
+
+	var nilAdapter *BufferAdapter
+	assert.Equal(t, "<nil>", nilAdapter.String())
+}
+
+func TestBufferAdapter_ReadFromWriteTo(t *testing.T) {
+	a := NewBufferAdapter(NewCompositeByteBuf(bb("pre-")))
+	n, err := a.ReadFrom(strings.NewReader("payload"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+
+	var out bytes.Buffer
+	m, err := io.Copy(&out, a)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), m)
+	assert.Equal(t, "pre-payload", out.String())
+	assert.Equal(t, 0, a.Len())
+	assert.ErrorIs(t, a.UnreadByte(), ErrInvalidUnread)
+}
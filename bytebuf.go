@@ -5,7 +5,9 @@ import (
 	"errors"
 	"io"
 	"math"
+	"sync"
 	"sync/atomic"
+	"weak"
 )
 
 // ByteBuf defines a byte buffer interface that is NOT concurrent-safe.
@@ -93,14 +95,38 @@ var ErrNilObject = errors.New("nil object")
 var ErrInsufficientSize = errors.New("insufficient size")
 var ErrRefCountUnderflow = errors.New("refcount underflow")
 
+// ErrBufferReleased is raised or returned when a managed buffer, or a
+// retained view of one, is read, written, grown or retained after its
+// final Release deallocated it.
+var ErrBufferReleased = errors.New("buffer already released")
+
+// ErrUnsupportedOperation is raised when an operation needs an optional
+// capability that the ByteBuf it is given does not implement.
+var ErrUnsupportedOperation = errors.New("operation not supported by wrapped byte buf")
+
 // Slicer is implemented by ByteBufs that expose zero-copy view APIs sharing
-// the same backing storage as the parent.
+// the same backing storage as the parent. The views get their own reference
+// count; see RetainedSlicer for views that keep the parent alive.
 type Slicer interface {
 	Slice(from, length int) ByteBuf
 	Duplicate() ByteBuf
 	ReadSlice(n int) ByteBuf
 }
 
+// RetainedSlicer is implemented by ByteBufs whose views can share the
+// parent's reference count. Each call retains the root buffer (the one
+// the views are ultimately derived from) once, and Retain/Release on the
+// returned view act on the root's counter, so a managed root (see Managed)
+// is not deallocated while a view still holds a reference, and its views
+// report ErrBufferReleased once it is. Release the view with Release when
+// done with it. ReleaseByteBuf on a root with live retained views defers
+// pooling to the Release of the last one.
+type RetainedSlicer interface {
+	RetainedSlice(from, length int) ByteBuf
+	RetainedDuplicate() ByteBuf
+	ReadRetainedSlice(n int) ByteBuf
+}
+
 // RefCounted is implemented by ByteBufs that participate in reference
 // counted lifecycle management. Fresh buffers start at refcount 1.
 type RefCounted interface {
@@ -109,6 +135,38 @@ type RefCounted interface {
 	RefCnt() int32
 }
 
+// TryReader is implemented by ByteBufs that offer non-panicking counterparts
+// of the relative readers. On failure each method returns
+// ErrInsufficientSize and leaves readerIndex untouched.
+type TryReader interface {
+	TrySkip(n int) error
+	TryReadBytes(n int) ([]byte, error)
+	TryReadByteBuf(n int) (ByteBuf, error)
+	TryReadInt16() (int16, error)
+	TryReadInt32() (int32, error)
+	TryReadInt64() (int64, error)
+	TryReadUInt16() (uint16, error)
+	TryReadUInt32() (uint32, error)
+	TryReadUInt64() (uint64, error)
+	TryReadFloat32() (float32, error)
+	TryReadFloat64() (float64, error)
+	TryReadInt16LE() (int16, error)
+	TryReadInt32LE() (int32, error)
+	TryReadInt64LE() (int64, error)
+	TryReadUInt16LE() (uint16, error)
+	TryReadUInt32LE() (uint32, error)
+	TryReadUInt64LE() (uint64, error)
+	TryReadFloat32LE() (float32, error)
+	TryReadFloat64LE() (float64, error)
+}
+
+var (
+	_ TryReader      = (*DefaultByteBuf)(nil)
+	_ RetainedSlicer = (*DefaultByteBuf)(nil)
+	_ io.ReaderFrom  = (*DefaultByteBuf)(nil)
+	_ io.WriterTo    = (*DefaultByteBuf)(nil)
+)
+
 // newDefaultByteBuf constructs a DefaultByteBuf with refcount 1 and a
 // poolIdx of -1 (unpooled).
 func newDefaultByteBuf() *DefaultByteBuf {
@@ -152,6 +210,99 @@ type DefaultByteBuf struct {
 	// when poolIdx >= 0.
 	poolIdx int32
 	refcnt  atomic.Int32
+	// statShard is the PoolStats shard a pooled buffer counts against,
+	// picked when the pool allocates it.
+	statShard uint8
+	// managed makes the Release that reaches refcount zero deallocate the
+	// buffer; see Managed.
+	managed atomic.Bool
+	// lastRune is the readerIndex before the last ReadRune plus one, or 0
+	// when UnreadRune is not allowed.
+	lastRune int
+	// side holds the bookkeeping few buffers need. Nil until first used,
+	// so plain buffers and the views of hot decode paths stay small.
+	side atomic.Pointer[bufSide]
+}
+
+// bufSide is the rarely used state of a DefaultByteBuf.
+type bufSide struct {
+	// oversize is the minCap of an oversize AcquireByteBuf until the
+	// buffer is released, and 0 otherwise.
+	oversize int
+	// discarded counts the bytes dropped from the front by compaction
+	// since the side struct was allocated; txPin is discarded plus the
+	// lowest index an open Transaction may roll back to, plus one, or 0
+	// outside any Transaction. Compaction never drops bytes at or after
+	// txPin.
+	discarded int
+	txPin     int
+	// leak is non-nil while the leak detector tracks the buffer.
+	leak atomic.Pointer[leakTracker]
+	// root is the buffer whose reference count a retained view shares, or
+	// nil for buffers with their own count.
+	root *DefaultByteBuf
+	// views lists the retained views of a root, so deallocate can strip
+	// their storage along with its own. Nil until the first one is made.
+	views atomic.Pointer[retainedViews]
+}
+
+// sideOf returns b's side struct, allocating it on first use.
+func (b *DefaultByteBuf) sideOf() *bufSide {
+	if sd := b.side.Load(); sd != nil {
+		return sd
+	}
+	b.side.CompareAndSwap(nil, &bufSide{})
+	return b.side.Load()
+}
+
+// viewRoot returns the root of a retained view, or nil.
+func (b *DefaultByteBuf) viewRoot() *DefaultByteBuf {
+	if sd := b.side.Load(); sd != nil {
+		return sd.root
+	}
+	return nil
+}
+
+// viewsOf returns the retained views of a root, or nil.
+func (b *DefaultByteBuf) viewsOf() *retainedViews {
+	if sd := b.side.Load(); sd != nil {
+		return sd.views.Load()
+	}
+	return nil
+}
+
+// leakOf returns b's leak tracker, or nil.
+func (b *DefaultByteBuf) leakOf() *leakTracker {
+	if sd := b.side.Load(); sd != nil {
+		return sd.leak.Load()
+	}
+	return nil
+}
+
+// retainedViews holds weak pointers to the retained views of one root and
+// the number of references they hold on it.
+type retainedViews struct {
+	mu   sync.Mutex
+	list []weak.Pointer[DefaultByteBuf]
+	refs atomic.Int32
+}
+
+// add records v, first pruning views that were garbage-collected when the
+// list is full, so it stays proportional to the views still alive.
+func (vs *retainedViews) add(v *DefaultByteBuf) {
+	vs.mu.Lock()
+	if len(vs.list) == cap(vs.list) {
+		live := vs.list[:0]
+		for _, w := range vs.list {
+			if w.Value() != nil {
+				live = append(live, w)
+			}
+		}
+		clear(vs.list[len(live):])
+		vs.list = live
+	}
+	vs.list = append(vs.list, weak.Make(v))
+	vs.mu.Unlock()
 }
 
 func (b *DefaultByteBuf) Write(p []byte) (n int, err error) {
@@ -169,6 +320,9 @@ func (b *DefaultByteBuf) Write(p []byte) (n int, err error) {
 func (b *DefaultByteBuf) Read(p []byte) (n int, err error) {
 	cpLen := b.ReadableBytes()
 	if cpLen == 0 {
+		if b.released() {
+			return 0, ErrBufferReleased
+		}
 		return 0, io.EOF
 	}
 
@@ -190,7 +344,7 @@ func (b *DefaultByteBuf) WriteAt(p []byte, offset int64) (n int, err error) {
 	// Validate offset and prevent int overflow when converting to index
 	maxInt := int(^uint(0) >> 1)
 	if offset < 0 || offset > int64(maxInt-pl) {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	off := int(offset)
 
@@ -214,6 +368,7 @@ func (b *DefaultByteBuf) Close() error {
 	b.writerIndex = 0
 	b.prevReaderIndex = 0
 	b.prevWriterIndex = 0
+	b.lastRune = 0
 	return nil
 }
 
@@ -254,12 +409,16 @@ func (b *DefaultByteBuf) Reset() ByteBuf {
 	b.writerIndex = 0
 	b.prevReaderIndex = 0
 	b.prevWriterIndex = 0
+	b.lastRune = 0
 	return b
 }
 
 func (b *DefaultByteBuf) Bytes() []byte {
 	// Returns a mutable view of the readable region.
 	// Mutating the returned slice will mutate the internal buffer directly.
+	if b.buf == nil && b.released() {
+		panic(ErrBufferReleased)
+	}
 	return b.buf[b.readerIndex:b.writerIndex]
 }
 
@@ -274,17 +433,17 @@ func (b *DefaultByteBuf) BytesCopy() []byte {
 }
 
 // Compact moves the readable region to the beginning of the buffer and adjusts indices (including marked indices).
+// Inside a Transaction the bytes it may roll back to are kept.
 func (b *DefaultByteBuf) Compact() ByteBuf {
-	if b.readerIndex == 0 {
+	shift := b.txFloor(b.readerIndex)
+	if shift == 0 {
 		return b
 	}
-	readable := b.ReadableBytes()
-	if readable > 0 {
-		copy(b.buf[0:], b.buf[b.readerIndex:b.writerIndex])
+	if b.writerIndex > shift {
+		copy(b.buf[0:], b.buf[shift:b.writerIndex])
 	}
-	shift := b.readerIndex
-	b.readerIndex = 0
-	b.writerIndex = readable
+	b.readerIndex -= shift
+	b.writerIndex -= shift
 	if b.prevReaderIndex > 0 {
 		if b.prevReaderIndex >= shift {
 			b.prevReaderIndex -= shift
@@ -299,6 +458,10 @@ func (b *DefaultByteBuf) Compact() ByteBuf {
 			b.prevWriterIndex = 0
 		}
 	}
+	if sd := b.side.Load(); sd != nil {
+		sd.discarded += shift
+	}
+	b.lastRune = 0
 	return b
 }
 
@@ -308,7 +471,7 @@ func (b *DefaultByteBuf) Compact() ByteBuf {
 // and compacts the readable region to index 0.
 func (b *DefaultByteBuf) EnsureCapacity(n int) ByteBuf {
 	if n < 0 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	if n == 0 {
 		return b
@@ -343,19 +506,38 @@ func (b *DefaultByteBuf) Cap() int {
 	return len(b.buf)
 }
 
+// released reports whether b is a managed buffer its final Release
+// deallocated, or a retained view of one.
+func (b *DefaultByteBuf) released() bool {
+	if r := b.viewRoot(); r != nil {
+		return r.released()
+	}
+	return b.managed.Load() && b.refcnt.Load() == 0
+}
+
+// errInsufficient is the error for a read past the readable bytes. A
+// deallocated buffer has none, so its reads report ErrBufferReleased.
+func (b *DefaultByteBuf) errInsufficient() error {
+	if b.released() {
+		return ErrBufferReleased
+	}
+	return ErrInsufficientSize
+}
+
 func (b *DefaultByteBuf) Grow(v int) ByteBuf {
 	if v <= 0 {
 		return b
 	}
+	if b.released() {
+		panic(ErrBufferReleased)
+	}
 
 	// Calculate the minimum offset to preserve marked indices
-	var offset int
-	if b.prevReaderIndex == 0 {
-		offset = b.readerIndex
-	} else {
-		offset = b.prevReaderIndex
-		b.prevReaderIndex = 0
+	offset := b.readerIndex
+	if b.prevReaderIndex > 0 {
+		offset = min(offset, b.prevReaderIndex)
 	}
+	offset = b.txFloor(offset)
 
 	// Only copy the active data region (from offset to writerIndex)
 	activeSize := b.writerIndex - offset
@@ -368,9 +550,16 @@ func (b *DefaultByteBuf) Grow(v int) ByteBuf {
 	// Adjust indices
 	b.readerIndex -= offset
 	b.writerIndex -= offset
+	if b.prevReaderIndex > 0 {
+		b.prevReaderIndex -= offset
+	}
 	if b.prevWriterIndex > 0 {
 		b.prevWriterIndex -= offset
 	}
+	if sd := b.side.Load(); sd != nil {
+		sd.discarded += offset
+	}
+	b.lastRune = 0
 
 	b.buf = tb
 	return b
@@ -380,13 +569,13 @@ func (b *DefaultByteBuf) Grow(v int) ByteBuf {
 // on a negative v or when v exceeds ReadableBytes.
 func (b *DefaultByteBuf) Skip(v int) ByteBuf {
 	if v < 0 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	if v == 0 {
 		return b
 	}
 	if b.ReadableBytes() < v {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	b.readerIndex += v
 	return b
@@ -584,7 +773,7 @@ func (b *DefaultByteBuf) WriteFloat64LE(v float64) ByteBuf {
 // MustReadByte reads a single byte and panics if insufficient data (original ReadByte behavior)
 func (b *DefaultByteBuf) MustReadByte() byte {
 	if b.readerIndex == b.writerIndex {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 
 	b.readerIndex++
@@ -594,7 +783,7 @@ func (b *DefaultByteBuf) MustReadByte() byte {
 // ReadByte implements io.ByteReader interface with error return
 func (b *DefaultByteBuf) ReadByte() (byte, error) {
 	if b.readerIndex == b.writerIndex {
-		return 0, ErrInsufficientSize
+		return 0, b.errInsufficient()
 	}
 
 	b.readerIndex++
@@ -603,14 +792,14 @@ func (b *DefaultByteBuf) ReadByte() (byte, error) {
 
 func (b *DefaultByteBuf) ReadBytes(len int) []byte {
 	if len < 0 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	if len == 0 {
 		return []byte{}
 	}
 
 	if b.ReadableBytes() < len {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 
 	b.readerIndex += len
@@ -634,6 +823,63 @@ func (b *DefaultByteBuf) ReadWriter(writer io.Writer) ByteBuf {
 	return b
 }
 
+// maxConsecutiveEmptyReads bounds how often ReadFrom retries a reader that
+// returns no data and no error before failing with io.ErrNoProgress.
+const maxConsecutiveEmptyReads = 100
+
+// ReadFrom implements io.ReaderFrom. It reads from r straight into the
+// writable tail until io.EOF, growing the buffer as needed, and returns the
+// number of bytes appended. Unlike WriteReader it reports read errors
+// instead of panicking; bytes read before the error stay in the buffer.
+func (b *DefaultByteBuf) ReadFrom(r io.Reader) (int64, error) {
+	if r == nil {
+		panic(ErrNilObject)
+	}
+	var total int64
+	for empty := 0; ; {
+		if b.Cap()-b.writerIndex < writeReaderChunk {
+			b.prepare(writeReaderChunk)
+		}
+		n, err := r.Read(b.buf[b.writerIndex:b.Cap()])
+		if n > 0 {
+			b.writerIndex += n
+			total += int64(n)
+			empty = 0
+		}
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			if empty++; empty >= maxConsecutiveEmptyReads {
+				return total, io.ErrNoProgress
+			}
+		}
+	}
+}
+
+// WriteTo implements io.WriterTo. It writes the readable region to w and
+// advances readerIndex by the bytes w accepted. A short write without an
+// error is reported as io.ErrShortWrite.
+func (b *DefaultByteBuf) WriteTo(w io.Writer) (int64, error) {
+	if w == nil {
+		panic(ErrNilObject)
+	}
+	readable := b.ReadableBytes()
+	if readable == 0 {
+		return 0, nil
+	}
+	n, err := w.Write(b.buf[b.readerIndex:b.writerIndex])
+	n = max(0, min(n, readable))
+	b.readerIndex += n
+	if err == nil && n < readable {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
 func (b *DefaultByteBuf) ReadInt16() int16 {
 	return int16(b.ReadUInt16())
 }
@@ -649,7 +895,7 @@ func (b *DefaultByteBuf) ReadInt64() int64 {
 func (b *DefaultByteBuf) ReadUInt16() uint16 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 2 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := binary.BigEndian.Uint16(b.buf[b.readerIndex:])
 	b.readerIndex += 2
@@ -659,7 +905,7 @@ func (b *DefaultByteBuf) ReadUInt16() uint16 {
 func (b *DefaultByteBuf) ReadUInt32() uint32 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 4 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := binary.BigEndian.Uint32(b.buf[b.readerIndex:])
 	b.readerIndex += 4
@@ -669,7 +915,7 @@ func (b *DefaultByteBuf) ReadUInt32() uint32 {
 func (b *DefaultByteBuf) ReadUInt64() uint64 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 8 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := binary.BigEndian.Uint64(b.buf[b.readerIndex:])
 	b.readerIndex += 8
@@ -679,7 +925,7 @@ func (b *DefaultByteBuf) ReadUInt64() uint64 {
 func (b *DefaultByteBuf) ReadFloat32() float32 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 4 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := math.Float32frombits(binary.BigEndian.Uint32(b.buf[b.readerIndex:]))
 	b.readerIndex += 4
@@ -689,7 +935,7 @@ func (b *DefaultByteBuf) ReadFloat32() float32 {
 func (b *DefaultByteBuf) ReadFloat64() float64 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 8 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := math.Float64frombits(binary.BigEndian.Uint64(b.buf[b.readerIndex:]))
 	b.readerIndex += 8
@@ -711,7 +957,7 @@ func (b *DefaultByteBuf) ReadInt64LE() int64 {
 func (b *DefaultByteBuf) ReadUInt16LE() uint16 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 2 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := binary.LittleEndian.Uint16(b.buf[b.readerIndex:])
 	b.readerIndex += 2
@@ -721,7 +967,7 @@ func (b *DefaultByteBuf) ReadUInt16LE() uint16 {
 func (b *DefaultByteBuf) ReadUInt32LE() uint32 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 4 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := binary.LittleEndian.Uint32(b.buf[b.readerIndex:])
 	b.readerIndex += 4
@@ -731,7 +977,7 @@ func (b *DefaultByteBuf) ReadUInt32LE() uint32 {
 func (b *DefaultByteBuf) ReadUInt64LE() uint64 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 8 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := binary.LittleEndian.Uint64(b.buf[b.readerIndex:])
 	b.readerIndex += 8
@@ -741,7 +987,7 @@ func (b *DefaultByteBuf) ReadUInt64LE() uint64 {
 func (b *DefaultByteBuf) ReadFloat32LE() float32 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 4 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := math.Float32frombits(binary.LittleEndian.Uint32(b.buf[b.readerIndex:]))
 	b.readerIndex += 4
@@ -751,7 +997,7 @@ func (b *DefaultByteBuf) ReadFloat32LE() float32 {
 func (b *DefaultByteBuf) ReadFloat64LE() float64 {
 	// Optimized: direct memory access without creating temporary slice
 	if b.ReadableBytes() < 8 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	result := math.Float64frombits(binary.LittleEndian.Uint64(b.buf[b.readerIndex:]))
 	b.readerIndex += 8
@@ -785,13 +1031,14 @@ func (b *DefaultByteBuf) prepare(i int) {
 // region (from the oldest preserved index) to the start. Marked indices are
 // adjusted so they remain valid after the move.
 func (b *DefaultByteBuf) growTo(newCap int) {
-	var offset int
-	if b.prevReaderIndex == 0 {
-		offset = b.readerIndex
-	} else {
-		offset = b.prevReaderIndex
-		b.prevReaderIndex = 0
+	if b.released() {
+		panic(ErrBufferReleased)
+	}
+	offset := b.readerIndex
+	if b.prevReaderIndex > 0 {
+		offset = min(offset, b.prevReaderIndex)
 	}
+	offset = b.txFloor(offset)
 
 	activeSize := b.writerIndex - offset
 	tb := make([]byte, newCap)
@@ -801,9 +1048,16 @@ func (b *DefaultByteBuf) growTo(newCap int) {
 
 	b.readerIndex -= offset
 	b.writerIndex -= offset
+	if b.prevReaderIndex > 0 {
+		b.prevReaderIndex -= offset
+	}
 	if b.prevWriterIndex > 0 {
 		b.prevWriterIndex -= offset
 	}
+	if sd := b.side.Load(); sd != nil {
+		sd.discarded += offset
+	}
+	b.lastRune = 0
 	b.buf = tb
 }
 
@@ -814,7 +1068,7 @@ func (b *DefaultByteBuf) growTo(newCap int) {
 // backing array inside the view and detaches it from b.
 func (b *DefaultByteBuf) Slice(from, length int) ByteBuf {
 	if from < 0 || length < 0 || from+length > b.ReadableBytes() {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	start := b.readerIndex + from
 	s := newDefaultByteBuf()
@@ -838,10 +1092,10 @@ func (b *DefaultByteBuf) Duplicate() ByteBuf {
 // consumed bytes. The returned view shares b's backing array.
 func (b *DefaultByteBuf) ReadSlice(n int) ByteBuf {
 	if n < 0 {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	if b.ReadableBytes() < n {
-		panic(ErrInsufficientSize)
+		panic(b.errInsufficient())
 	}
 	start := b.readerIndex
 	b.readerIndex += n
@@ -851,24 +1105,215 @@ func (b *DefaultByteBuf) ReadSlice(n int) ByteBuf {
 	return s
 }
 
+// RetainedSlice is Slice returning a view that shares b's reference count.
+func (b *DefaultByteBuf) RetainedSlice(from, length int) ByteBuf {
+	return b.retainView(b.Slice(from, length).(*DefaultByteBuf))
+}
+
+// RetainedDuplicate is Duplicate returning a view that shares b's
+// reference count.
+func (b *DefaultByteBuf) RetainedDuplicate() ByteBuf {
+	return b.retainView(b.Duplicate().(*DefaultByteBuf))
+}
+
+// ReadRetainedSlice is ReadSlice returning a view that shares b's
+// reference count.
+func (b *DefaultByteBuf) ReadRetainedSlice(n int) ByteBuf {
+	return b.retainView(b.ReadSlice(n).(*DefaultByteBuf))
+}
+
+// retainView retains the root of b and ties v's reference count to it.
+func (b *DefaultByteBuf) retainView(v *DefaultByteBuf) ByteBuf {
+	root := b
+	if r := b.viewRoot(); r != nil {
+		root = r
+	}
+	root.Retain()
+	v.sideOf().root = root
+	rs := root.sideOf()
+	vs := rs.views.Load()
+	if vs == nil {
+		rs.views.CompareAndSwap(nil, &retainedViews{})
+		vs = rs.views.Load()
+	}
+	vs.add(v)
+	vs.refs.Add(1)
+	return v
+}
+
 // Retain increments the reference count and returns the buffer for chaining.
+// Retaining a managed buffer that was already deallocated panics with
+// ErrBufferReleased. A retained view retains its root.
 func (b *DefaultByteBuf) Retain() ByteBuf {
-	b.refcnt.Add(1)
+	if r := b.viewRoot(); r != nil {
+		r.Retain()
+		if vs := r.viewsOf(); vs != nil {
+			vs.refs.Add(1)
+		}
+		return b
+	}
+	n := b.refcnt.Add(1)
+	if n == 1 && b.managed.Load() {
+		b.refcnt.Add(-1)
+		panic(ErrBufferReleased)
+	}
+	if t := b.leakOf(); t != nil {
+		t.record("retain", "", n)
+	}
 	return b
 }
 
 // Release decrements the reference count. It returns true when the counter
-// reaches zero, at which point the caller owns the final drop. Panics on
-// underflow.
+// reaches zero, at which point the caller owns the final drop, unless the
+// buffer is managed, in which case Release has already deallocated it.
+// Panics on underflow. A retained view releases its root.
 func (b *DefaultByteBuf) Release() bool {
+	if r := b.viewRoot(); r != nil {
+		if vs := r.viewsOf(); vs != nil {
+			vs.refs.Add(-1)
+		}
+		return r.Release()
+	}
 	n := b.refcnt.Add(-1)
 	if n < 0 {
+		b.refcnt.Add(1)
 		panic(ErrRefCountUnderflow)
 	}
+	if t := b.leakOf(); t != nil {
+		t.record("release", "", n)
+		if n == 0 {
+			closeLeak(b)
+		}
+	}
+	if n == 0 && b.managed.Load() {
+		b.deallocate()
+	}
 	return n == 0
 }
 
-// RefCnt returns the current reference count.
+// RefCnt returns the current reference count, the root's for a retained
+// view.
 func (b *DefaultByteBuf) RefCnt() int32 {
+	if r := b.viewRoot(); r != nil {
+		return r.refcnt.Load()
+	}
 	return b.refcnt.Load()
 }
+
+// TrySkip advances readerIndex by n bytes. It returns ErrInsufficientSize
+// without moving the index when n is negative or exceeds ReadableBytes.
+func (b *DefaultByteBuf) TrySkip(n int) error {
+	if n < 0 || b.ReadableBytes() < n {
+		return b.errInsufficient()
+	}
+	b.readerIndex += n
+	return nil
+}
+
+// TryReadBytes is the non-panicking form of ReadBytes.
+func (b *DefaultByteBuf) TryReadBytes(n int) ([]byte, error) {
+	if n < 0 || b.ReadableBytes() < n {
+		return nil, b.errInsufficient()
+	}
+	return b.ReadBytes(n), nil
+}
+
+// TryReadByteBuf is the non-panicking form of ReadByteBuf.
+func (b *DefaultByteBuf) TryReadByteBuf(n int) (ByteBuf, error) {
+	if n < 0 || b.ReadableBytes() < n {
+		return nil, b.errInsufficient()
+	}
+	return b.ReadByteBuf(n), nil
+}
+
+func (b *DefaultByteBuf) TryReadInt16() (int16, error) {
+	v, err := b.TryReadUInt16()
+	return int16(v), err
+}
+
+func (b *DefaultByteBuf) TryReadInt32() (int32, error) {
+	v, err := b.TryReadUInt32()
+	return int32(v), err
+}
+
+func (b *DefaultByteBuf) TryReadInt64() (int64, error) {
+	v, err := b.TryReadUInt64()
+	return int64(v), err
+}
+
+func (b *DefaultByteBuf) TryReadUInt16() (uint16, error) {
+	if b.ReadableBytes() < 2 {
+		return 0, b.errInsufficient()
+	}
+	return b.ReadUInt16(), nil
+}
+
+func (b *DefaultByteBuf) TryReadUInt32() (uint32, error) {
+	if b.ReadableBytes() < 4 {
+		return 0, b.errInsufficient()
+	}
+	return b.ReadUInt32(), nil
+}
+
+func (b *DefaultByteBuf) TryReadUInt64() (uint64, error) {
+	if b.ReadableBytes() < 8 {
+		return 0, b.errInsufficient()
+	}
+	return b.ReadUInt64(), nil
+}
+
+func (b *DefaultByteBuf) TryReadFloat32() (float32, error) {
+	v, err := b.TryReadUInt32()
+	return math.Float32frombits(v), err
+}
+
+func (b *DefaultByteBuf) TryReadFloat64() (float64, error) {
+	v, err := b.TryReadUInt64()
+	return math.Float64frombits(v), err
+}
+
+func (b *DefaultByteBuf) TryReadInt16LE() (int16, error) {
+	v, err := b.TryReadUInt16LE()
+	return int16(v), err
+}
+
+func (b *DefaultByteBuf) TryReadInt32LE() (int32, error) {
+	v, err := b.TryReadUInt32LE()
+	return int32(v), err
+}
+
+func (b *DefaultByteBuf) TryReadInt64LE() (int64, error) {
+	v, err := b.TryReadUInt64LE()
+	return int64(v), err
+}
+
+func (b *DefaultByteBuf) TryReadUInt16LE() (uint16, error) {
+	if b.ReadableBytes() < 2 {
+		return 0, b.errInsufficient()
+	}
+	return b.ReadUInt16LE(), nil
+}
+
+func (b *DefaultByteBuf) TryReadUInt32LE() (uint32, error) {
+	if b.ReadableBytes() < 4 {
+		return 0, b.errInsufficient()
+	}
+	return b.ReadUInt32LE(), nil
+}
+
+func (b *DefaultByteBuf) TryReadUInt64LE() (uint64, error) {
+	if b.ReadableBytes() < 8 {
+		return 0, b.errInsufficient()
+	}
+	return b.ReadUInt64LE(), nil
+}
+
+func (b *DefaultByteBuf) TryReadFloat32LE() (float32, error) {
+	v, err := b.TryReadUInt32LE()
+	return math.Float32frombits(v), err
+}
+
+func (b *DefaultByteBuf) TryReadFloat64LE() (float64, error) {
+	v, err := b.TryReadUInt64LE()
+	return math.Float64frombits(v), err
+}
@@ -0,0 +1,85 @@
+package buf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// io.Copy takes the ReaderFrom fast path into both buffer types.
+func TestReadFrom_CopyFastPath(t *testing.T) {
+	for _, dst := range []ByteBuf{EmptyByteBuf(), NewCompositeByteBuf(bb("pre"))} {
+		before := dst.ReadableBytes()
+		n, err := io.Copy(dst, &slowReader{total: 10000, chunk: 333, pat: 7})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10000), n)
+		assert.Equal(t, before+10000, dst.ReadableBytes())
+	}
+}
+
+// Read errors are returned with the partial progress kept.
+func TestReadFrom_Error(t *testing.T) {
+	for _, dst := range []ByteBuf{EmptyByteBuf(), NewCompositeByteBuf()} {
+		n, err := dst.(io.ReaderFrom).ReadFrom(&errorReaderType{data: []byte("test"), errAfter: 2})
+		assert.EqualError(t, err, "test error")
+		assert.Equal(t, int64(4), n)
+		assert.Equal(t, "test", string(dst.BytesCopy()))
+	}
+
+	n, err := EmptyByteBuf().(io.ReaderFrom).ReadFrom(emptyReader{})
+	assert.ErrorIs(t, err, io.ErrNoProgress)
+	assert.Equal(t, int64(0), n)
+}
+
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) { return 0, nil }
+
+func TestWriteTo_DefaultByteBuf(t *testing.T) {
+	b := bb("xxpayload")
+	b.Skip(2)
+	var out bytes.Buffer
+	n, err := io.Copy(&out, b)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	assert.Equal(t, "payload", out.String())
+	assert.Equal(t, 0, b.ReadableBytes())
+
+	b = bb("payload")
+	sw := &shortWriter{max: 3}
+	n, err = b.(io.WriterTo).WriteTo(sw)
+	assert.EqualError(t, err, "forced error after partial write")
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, "load", string(b.Bytes()))
+}
+
+// A writer that accepts less without an error is reported as a short write
+// instead of silently skipping bytes.
+func TestWriteTo_ShortWriteWithoutError(t *testing.T) {
+	for _, src := range []ByteBuf{bb("abcdef"), NewCompositeByteBuf(bb("abc"), bb("def"))} {
+		n, err := src.(io.WriterTo).WriteTo(&lazyWriter{max: 2})
+		assert.ErrorIs(t, err, io.ErrShortWrite)
+		assert.Equal(t, int64(2), n)
+		assert.Equal(t, "cdef", string(src.BytesCopy()))
+	}
+}
+
+type lazyWriter struct{ max int }
+
+func (w *lazyWriter) Write(p []byte) (int, error) { return min(w.max, len(p)), nil }
+
+func TestOrder_ReadFromWriteTo(t *testing.T) {
+	le := Order(EmptyByteBuf(), binary.LittleEndian)
+	_, err := io.Copy(le, bytes.NewReader([]byte{1, 0}))
+	assert.NoError(t, err)
+	var out bytes.Buffer
+	_, err = io.Copy(&out, le)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 0}, out.Bytes())
+
+	_, err = Order(EmptyByteBuf(), nil).(io.ReaderFrom).ReadFrom(&errorReaderType{errAfter: 0})
+	assert.EqualError(t, err, "test error")
+}
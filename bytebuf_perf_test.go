@@ -36,6 +36,15 @@ func TestClose_StillReleasesStorage(t *testing.T) {
 	assert.Nil(t, b.buf)
 }
 
+// Views made by Slice pay for every DefaultByteBuf field, so the rarely
+// used bookkeeping stays behind the side pointer, and a plain view does
+// not allocate it.
+func TestDefaultByteBuf_Size(t *testing.T) {
+	assert.LessOrEqual(t, unsafe.Sizeof(DefaultByteBuf{}), uintptr(88))
+	v := bb("abc").(Slicer).Slice(0, 2).(*DefaultByteBuf)
+	assert.Nil(t, v.side.Load())
+}
+
 // Writes that fit the preserved capacity must reuse the same backing array.
 func TestReuse_AfterReset_NoRealloc(t *testing.T) {
 	b := EmptyByteBuf().(*DefaultByteBuf)
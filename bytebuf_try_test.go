@@ -0,0 +1,81 @@
+package buf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Both implementations expose TryReader through a type assertion.
+func TestTryReader_TypeAssertion(t *testing.T) {
+	_, ok := EmptyByteBuf().(TryReader)
+	assert.True(t, ok)
+	_, ok = NewCompositeByteBuf().(TryReader)
+	assert.True(t, ok)
+}
+
+// Successful reads return the same values as the panicking readers.
+func TestTryReader_RoundTrip(t *testing.T) {
+	b := EmptyByteBuf()
+	b.WriteUInt16(0xBEEF).WriteInt32LE(-7).WriteFloat64(math.Pi).WriteString("xyz")
+	r := b.(TryReader)
+
+	u16, err := r.TryReadUInt16()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0xBEEF), u16)
+	i32, err := r.TryReadInt32LE()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-7), i32)
+	f64, err := r.TryReadFloat64()
+	assert.NoError(t, err)
+	assert.Equal(t, math.Pi, f64)
+	assert.NoError(t, r.TrySkip(1))
+	bs, err := r.TryReadBytes(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("yz"), bs)
+}
+
+// A short read reports ErrInsufficientSize and leaves readerIndex alone.
+func TestTryReader_Insufficient_KeepsReaderIndex(t *testing.T) {
+	b := NewByteBuf([]byte{1, 2, 3})
+	b.Skip(1)
+	r := b.(TryReader)
+
+	_, err := r.TryReadUInt32()
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	_, err = r.TryReadInt64LE()
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	_, err = r.TryReadBytes(3)
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	_, err = r.TryReadBytes(-1)
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	_, err = r.TryReadByteBuf(5)
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	assert.ErrorIs(t, r.TrySkip(3), ErrInsufficientSize)
+	assert.ErrorIs(t, r.TrySkip(-1), ErrInsufficientSize)
+	assert.Equal(t, 1, b.ReaderIndex())
+
+	v, err := r.TryReadUInt16LE()
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(0x0302), v)
+}
+
+// Composite Try readers cross component boundaries and fail atomically.
+func TestTryReader_Composite_CrossBoundary(t *testing.T) {
+	cb := NewCompositeByteBuf(bbBytes([]byte{0x01, 0x02}), bbBytes([]byte{0x03, 0x04, 0x05}))
+	c := cb.(TryReader)
+	v, err := c.TryReadUInt32()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x01020304), v)
+
+	_, err = c.TryReadUInt16()
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	_, err = c.TryReadFloat32LE()
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	assert.Equal(t, 4, cb.ReaderIndex())
+
+	bs, err := c.TryReadBytes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x05}, bs)
+}
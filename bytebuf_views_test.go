@@ -2,6 +2,7 @@ package buf
 
 import (
 	"bytes"
+	"encoding/binary"
 	"sync/atomic"
 	"testing"
 	"unsafe"
@@ -206,6 +207,16 @@ func TestPool_Release_IgnoresViews(t *testing.T) {
 	ReleaseByteBuf(parent)
 }
 
+// Releasing an Order view pools the buffer it wraps.
+func TestPool_Release_UnwrapsOrdered(t *testing.T) {
+	b := AcquireByteBuf(64).(*DefaultByteBuf)
+	b.WriteString("abc")
+	ReleaseByteBuf(Order(b, binary.LittleEndian))
+	assert.Equal(t, int32(0), b.RefCnt())
+	assert.Equal(t, 0, b.ReadableBytes())
+	ReleaseByteBuf((*DefaultByteBuf)(nil))
+}
+
 // AcquireByteBuf(0) returns the smallest class so a nominal call never
 // panics and the caller receives a usable buffer.
 func TestPool_Acquire_ZeroIsSmallestClass(t *testing.T) {
@@ -219,6 +230,190 @@ func TestPool_Acquire_NegativePanics(t *testing.T) {
 	assert.Panics(t, func() { AcquireByteBuf(-1) })
 }
 
+// The final Release of a managed buffer returns its array to the pool and
+// leaves the buffer detectably released.
+func TestPool_Managed_ReleaseDeallocates(t *testing.T) {
+	const class = 1 // 256 bytes
+	before := PoolStats().Classes[class]
+	b := AcquireManagedByteBuf(200)
+	b.WriteString("shared")
+	b.Retain()
+
+	assert.False(t, b.Release())
+	assert.Equal(t, "shared", string(b.Bytes()))
+	assert.True(t, b.Release())
+	after := PoolStats().Classes[class]
+	assert.Equal(t, uint64(1), after.Releases-before.Releases)
+	assert.Equal(t, before.Outstanding, after.Outstanding)
+
+	assert.Equal(t, 0, b.Cap())
+	assert.Equal(t, 0, b.ReadableBytes())
+	assert.Equal(t, int32(-1), b.poolIdx)
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.WriteString("x") })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.Grow(8) })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.Retain() })
+	assert.PanicsWithValue(t, ErrRefCountUnderflow, func() { b.Release() })
+	assert.PanicsWithValue(t, ErrRefCountUnderflow, func() { ReleaseByteBuf(b) })
+
+	// Reads report the release rather than an empty buffer.
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.MustReadByte() })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.ReadUInt32() })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.ReadBytes(1) })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.Skip(1) })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.Bytes() })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { b.GetByte(0) })
+	_, err := b.ReadByte()
+	assert.ErrorIs(t, err, ErrBufferReleased)
+	_, err = b.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, ErrBufferReleased)
+	_, err = b.TryReadUInt16()
+	assert.ErrorIs(t, err, ErrBufferReleased)
+	assert.ErrorIs(t, b.TrySkip(1), ErrBufferReleased)
+	_, err = b.ReadVarUInt64()
+	assert.ErrorIs(t, err, ErrBufferReleased)
+}
+
+// Unpooled managed buffers just drop their storage, and ReleaseByteBuf on a
+// managed buffer is one Release.
+func TestPool_Managed_UnpooledAndReleaseByteBuf(t *testing.T) {
+	u := Managed(NewByteBufString("abc").(*DefaultByteBuf))
+	assert.True(t, u.Release())
+	assert.Nil(t, u.buf)
+
+	big := AcquireManagedByteBuf(1 << 20)
+	big.Retain()
+	ReleaseByteBuf(big)
+	assert.Equal(t, int32(1), big.RefCnt())
+	assert.Equal(t, 1<<20, big.Cap())
+	ReleaseByteBuf(big)
+	assert.Equal(t, 0, big.Cap())
+
+	o := Order(Managed(NewByteBufString("abc").(*DefaultByteBuf)), nil)
+	assert.True(t, o.(RefCounted).Release())
+	assert.Equal(t, 0, o.Cap())
+}
+
+// --- Retained views ------------------------------------------------------
+
+// Retained views share the root's reference count, and the managed pooled
+// root goes back to the pool only once every view has been released.
+func TestRetainedViews_ShareRootRefcount(t *testing.T) {
+	const class = 0 // 64 bytes
+	before := PoolStats().Classes[class]
+	root := AcquireManagedByteBuf(64)
+	root.WriteString("header:payload")
+
+	hdr := root.ReadRetainedSlice(6).(*DefaultByteBuf)
+	dup := root.RetainedDuplicate().(*DefaultByteBuf)
+	body := dup.RetainedSlice(1, 7).(*DefaultByteBuf)
+	assert.Same(t, root, body.viewRoot(), "views of views share the root")
+	assert.Equal(t, int32(4), root.RefCnt())
+	assert.Equal(t, int32(4), hdr.RefCnt())
+	assert.Equal(t, "payload", string(body.Bytes()))
+
+	hdr.Retain()
+	assert.Equal(t, int32(5), root.RefCnt())
+	assert.False(t, hdr.Release())
+
+	assert.False(t, root.Release())
+	assert.Equal(t, int32(3), dup.RefCnt())
+	assert.Equal(t, before.Releases, PoolStats().Classes[class].Releases)
+	assert.Equal(t, "header", string(hdr.Bytes()))
+
+	ReleaseByteBuf(hdr) // a view: ignored
+	assert.Equal(t, int32(3), dup.RefCnt())
+	assert.False(t, hdr.Release())
+	assert.False(t, dup.Release())
+	assert.True(t, body.Release())
+	assert.Equal(t, before.Releases+1, PoolStats().Classes[class].Releases)
+	assert.Equal(t, 0, root.Cap())
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { body.Retain() })
+	assert.Panics(t, func() { dup.Release() })
+}
+
+// Reads through a retained view after its root was deallocated report
+// ErrBufferReleased rather than the recycled array's new content.
+func TestRetainedViews_ReleasedWithRoot(t *testing.T) {
+	root := AcquireManagedByteBuf(64)
+	root.WriteString("header:payload")
+	arr := unsafe.SliceData(root.buf)
+	view := root.RetainedSlice(7, 7).(*DefaultByteBuf)
+	assert.False(t, view.Release())
+	assert.True(t, root.Release())
+
+	reused := AcquireByteBuf(64).(*DefaultByteBuf)
+	defer ReleaseByteBuf(reused)
+	reused.WriteString("overwritten by the next owner")
+	if unsafe.SliceData(reused.buf) != arr {
+		t.Log("pool did not hand the array back; checking the view alone")
+	}
+
+	assert.Equal(t, 0, view.ReadableBytes())
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { view.Bytes() })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { view.ReadBytes(1) })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { view.GetByte(0) })
+	assert.PanicsWithValue(t, ErrBufferReleased, func() { view.WriteString("x") })
+	_, err := view.ReadByte()
+	assert.ErrorIs(t, err, ErrBufferReleased)
+	_, err = view.Read(make([]byte, 4))
+	assert.ErrorIs(t, err, ErrBufferReleased)
+	_, err = view.TryReadUInt32()
+	assert.ErrorIs(t, err, ErrBufferReleased)
+}
+
+// ReleaseByteBuf on a root with a live retained view defers pooling to
+// the view's Release, so a fresh acquire cannot recycle the view's bytes.
+func TestRetainedViews_ReleaseByteBufDefers(t *testing.T) {
+	const class = 0
+	before := PoolStats().Classes[class]
+	root := AcquireByteBuf(64).(*DefaultByteBuf)
+	root.WriteString("hello")
+	view := root.RetainedSlice(0, 5)
+	ReleaseByteBuf(root)
+	assert.Equal(t, before.Releases, PoolStats().Classes[class].Releases)
+	assert.Equal(t, int32(1), view.(RefCounted).RefCnt())
+
+	fresh := AcquireByteBuf(64).(*DefaultByteBuf)
+	assert.NotSame(t, root, fresh)
+	fresh.WriteString("XXXXX")
+	assert.Equal(t, "hello", string(view.BytesCopy()))
+
+	assert.True(t, view.(RefCounted).Release())
+	assert.Equal(t, int32(1), fresh.RefCnt())
+	assert.Equal(t, before.Releases+1, PoolStats().Classes[class].Releases)
+	ReleaseByteBuf(fresh)
+
+	// A root whose views are gone pools at once and forgets them; a plain
+	// Retain does not defer.
+	root = AcquireByteBuf(64).(*DefaultByteBuf)
+	root.RetainedDuplicate().(RefCounted).Release()
+	root.Retain()
+	ReleaseByteBuf(root)
+	assert.False(t, root.managed.Load())
+	assert.Nil(t, root.viewsOf())
+}
+
+// Without retained views ReleaseByteBuf pools the buffer right away, and a
+// plain Slice keeps its own count.
+func TestRetainedViews_PlainViewsUnchanged(t *testing.T) {
+	root := AcquireByteBuf(64).(*DefaultByteBuf)
+	root.WriteString("abc")
+	view := root.Slice(0, 2).(*DefaultByteBuf)
+	assert.Equal(t, int32(1), view.RefCnt())
+	assert.Nil(t, view.viewRoot())
+	ReleaseByteBuf(root)
+	assert.Equal(t, int32(0), root.RefCnt())
+	assert.False(t, root.managed.Load())
+
+	o := Order(NewByteBufString("abcd"), nil)
+	v := o.(RetainedSlicer).RetainedSlice(1, 2)
+	assert.Equal(t, int32(2), o.(RefCounted).RefCnt())
+	_, ordered := v.(OrderedByteBuf)
+	assert.True(t, ordered)
+	assert.False(t, v.(RefCounted).Release())
+	assert.Equal(t, int32(1), o.(RefCounted).RefCnt())
+}
+
 // --- NewSharedByteBuf ----------------------------------------------------
 
 // NewSharedByteBuf wraps the provided slice without copying.
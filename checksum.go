@@ -0,0 +1,85 @@
+package buf
+
+import (
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Checksum feeds the readable region of bb into h without moving indices.
+// Composite components are written one segment at a time, so the region is
+// never flattened. h is not reset first.
+func Checksum(bb ByteBuf, h hash.Hash) {
+	if bb == nil || h == nil {
+		panic(ErrNilObject)
+	}
+	ChecksumAt(bb, h, bb.ReaderIndex(), bb.ReadableBytes())
+}
+
+// ChecksumAt feeds length bytes of bb starting at the absolute index from
+// into h. Indices follow the AbsoluteAccessor getters and the range must lie
+// within [0, WriterIndex()); otherwise it panics with ErrInsufficientSize.
+func ChecksumAt(bb ByteBuf, h hash.Hash, from, length int) {
+	if bb == nil || h == nil {
+		panic(ErrNilObject)
+	}
+	checkRange(bb, from, length)
+	cur := newSegmentCursor(bb, from, length)
+	for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+		h.Write(seg)
+	}
+}
+
+// CRC32 returns the CRC-32 of the readable region of bb using tab, or the
+// IEEE polynomial when tab is nil.
+func CRC32(bb ByteBuf, tab *crc32.Table) uint32 {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	return CRC32At(bb, tab, bb.ReaderIndex(), bb.ReadableBytes())
+}
+
+// CRC32At is CRC32 over length bytes starting at the absolute index from.
+func CRC32At(bb ByteBuf, tab *crc32.Table, from, length int) uint32 {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	if tab == nil {
+		tab = crc32.IEEETable
+	}
+	checkRange(bb, from, length)
+	var crc uint32
+	cur := newSegmentCursor(bb, from, length)
+	for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+		crc = crc32.Update(crc, tab, seg)
+	}
+	return crc
+}
+
+// CRC32C returns the CRC-32 of the readable region of bb using the
+// Castagnoli polynomial.
+func CRC32C(bb ByteBuf) uint32 {
+	return CRC32(bb, castagnoliTable)
+}
+
+// CRC32CAt is CRC32C over length bytes starting at the absolute index from.
+func CRC32CAt(bb ByteBuf, from, length int) uint32 {
+	return CRC32At(bb, castagnoliTable, from, length)
+}
+
+// Adler32 returns the Adler-32 checksum of the readable region of bb.
+func Adler32(bb ByteBuf) uint32 {
+	h := adler32.New()
+	Checksum(bb, h)
+	return h.Sum32()
+}
+
+// Adler32At is Adler32 over length bytes starting at the absolute index
+// from.
+func Adler32At(bb ByteBuf, from, length int) uint32 {
+	h := adler32.New()
+	ChecksumAt(bb, h, from, length)
+	return h.Sum32()
+}
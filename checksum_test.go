@@ -0,0 +1,47 @@
+package buf
+
+import (
+	"crypto/sha256"
+	"hash/adler32"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Checksums over a composite match the flat computation and leave the
+// component layout alone.
+func TestChecksum_Composite(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+	c := NewCompositeByteBuf(bbBytes(data[:7]), bbBytes(data[7:20]), bbBytes(data[20:]))
+	before := len(asDefault(c).components)
+
+	assert.Equal(t, crc32.ChecksumIEEE(data), CRC32(c, nil))
+	assert.Equal(t, crc32.Checksum(data, crc32.MakeTable(crc32.Koopman)), CRC32(c, crc32.MakeTable(crc32.Koopman)))
+	assert.Equal(t, crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)), CRC32C(c))
+	assert.Equal(t, adler32.Checksum(data), Adler32(c))
+
+	h := sha256.New()
+	Checksum(c, h)
+	want := sha256.Sum256(data)
+	assert.Equal(t, want[:], h.Sum(nil))
+
+	assert.Equal(t, before, len(asDefault(c).components))
+	assert.Equal(t, 0, c.ReaderIndex())
+}
+
+// Ranges use absolute indices and may reach into consumed bytes.
+func TestChecksum_Ranges(t *testing.T) {
+	data := []byte("0123456789abcdef")
+	for _, b := range []ByteBuf{bbBytes(data), NewCompositeByteBuf(bbBytes(data[:5]), bbBytes(data[5:]))} {
+		b.Skip(8)
+		assert.Equal(t, crc32.ChecksumIEEE(data[8:]), CRC32(b, nil))
+		assert.Equal(t, crc32.ChecksumIEEE(data[3:12]), CRC32At(b, nil, 3, 9))
+		assert.Equal(t, crc32.Checksum(data[4:6], crc32.MakeTable(crc32.Castagnoli)), CRC32CAt(b, 4, 2))
+		assert.Equal(t, adler32.Checksum(data[0:16]), Adler32At(b, 0, 16))
+		assert.Equal(t, uint32(0), CRC32At(b, nil, 16, 0))
+		assert.PanicsWithValue(t, ErrInsufficientSize, func() { CRC32At(b, nil, 10, 7) })
+		assert.PanicsWithValue(t, ErrInsufficientSize, func() { Adler32At(b, -1, 2) })
+		assert.Equal(t, 8, b.ReaderIndex())
+	}
+}
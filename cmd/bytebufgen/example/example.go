@@ -0,0 +1,54 @@
+// Package example holds wire structs used to exercise bytebufgen. The
+// generated methods live in example_bytebuf.go.
+package example
+
+import "sync"
+
+//go:generate go run github.com/yetiz-org/goth-bytebuf/cmd/bytebufgen
+
+// Kind is a named integer type; bytebufgen converts through its underlying
+// type.
+type Kind uint8
+
+//bytebuf:generate
+type Header struct {
+	Magic  uint16
+	Kind   Kind
+	Length uint32 `bytebuf:"u24,le"`
+}
+
+//bytebuf:generate
+type Message struct {
+	Header   Header
+	ID       int64    `bytebuf:"varint"`
+	Seq      uint32   `bytebuf:"varint"`
+	Delta    int32    `bytebuf:"i16"`
+	Ratio    float64  `bytebuf:"le"`
+	Name     string   `bytebuf:"len=u16"`
+	Payload  []byte   `bytebuf:"len=varint"`
+	Ports    []uint16 `bytebuf:"len=u8,le"`
+	Reserved [2]byte  `bytebuf:"skip=4"`
+	Matrix   [2]int16
+	Enabled  bool
+	Tags     []Header `bytebuf:"len=u8"`
+	Note     string   `bytebuf:"-"`
+}
+
+// Envelope embeds Header, which is encoded inline like a field named
+// Header; the unexported mutex is not encoded.
+//
+//bytebuf:generate
+type Envelope struct {
+	Header
+	mu   sync.Mutex
+	Body uint16
+}
+
+// Flags holds slices and arrays of the named Kind, which go element by
+// element rather than through the []byte fast path.
+//
+//bytebuf:generate
+type Flags struct {
+	Kinds []Kind `bytebuf:"len=u8"`
+	Mask  [3]Kind
+}
@@ -0,0 +1,299 @@
+// Code generated by bytebufgen. DO NOT EDIT.
+
+package example
+
+import buf "github.com/yetiz-org/goth-bytebuf"
+
+// EncodeTo writes m to bb. It panics with buf.ErrValueOverflow when a
+// value does not fit its wire width.
+func (m *Envelope) EncodeTo(bb buf.ByteBuf) {
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	m.Header.EncodeTo(bb)
+	bb.WriteUInt16(m.Body)
+}
+
+// DecodeFrom reads m from bb. It returns buf.ErrInsufficientSize when bb
+// runs out of readable bytes.
+func (m *Envelope) DecodeFrom(bb buf.ByteBuf) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r == buf.ErrInsufficientSize || r == buf.ErrCompositeOutOfRange {
+				err = buf.ErrInsufficientSize
+				return
+			}
+			panic(r)
+		}
+	}()
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	if err := m.Header.DecodeFrom(bb); err != nil {
+		return err
+	}
+	m.Body = bb.ReadUInt16()
+	return nil
+}
+
+// EncodedSize returns the number of bytes EncodeTo writes for m.
+func (m *Envelope) EncodedSize() int {
+	n := 2
+	n += m.Header.EncodedSize()
+	return n
+}
+
+// EncodeTo writes m to bb. It panics with buf.ErrValueOverflow when a
+// value does not fit its wire width.
+func (m *Flags) EncodeTo(bb buf.ByteBuf) {
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	if len(m.Kinds) > 255 {
+		panic(buf.ErrValueOverflow)
+	}
+	bb.AppendByte(byte(len(m.Kinds)))
+	for i1 := range m.Kinds {
+		bb.AppendByte(byte(m.Kinds[i1]))
+	}
+	for i2 := range m.Mask {
+		bb.AppendByte(byte(m.Mask[i2]))
+	}
+}
+
+// DecodeFrom reads m from bb. It returns buf.ErrInsufficientSize when bb
+// runs out of readable bytes.
+func (m *Flags) DecodeFrom(bb buf.ByteBuf) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r == buf.ErrInsufficientSize || r == buf.ErrCompositeOutOfRange {
+				err = buf.ErrInsufficientSize
+				return
+			}
+			panic(r)
+		}
+	}()
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	{
+		n := uint64(bb.MustReadByte())
+		if n > uint64(bb.ReadableBytes()) {
+			return buf.ErrInsufficientSize
+		}
+		m.Kinds = make([]Kind, n)
+	}
+	for i1 := range m.Kinds {
+		m.Kinds[i1] = Kind(bb.MustReadByte())
+	}
+	for i2 := range m.Mask {
+		m.Mask[i2] = Kind(bb.MustReadByte())
+	}
+	return nil
+}
+
+// EncodedSize returns the number of bytes EncodeTo writes for m.
+func (m *Flags) EncodedSize() int {
+	n := 1
+	n += len(m.Kinds)
+	n += len(m.Mask)
+	return n
+}
+
+// EncodeTo writes m to bb. It panics with buf.ErrValueOverflow when a
+// value does not fit its wire width.
+func (m *Header) EncodeTo(bb buf.ByteBuf) {
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	bb.WriteUInt16(m.Magic)
+	bb.AppendByte(byte(m.Kind))
+	if uint64(m.Length) > 16777215 {
+		panic(buf.ErrValueOverflow)
+	}
+	bb.AppendByte(byte(m.Length))
+	bb.AppendByte(byte(m.Length >> 8))
+	bb.AppendByte(byte(m.Length >> 16))
+}
+
+// DecodeFrom reads m from bb. It returns buf.ErrInsufficientSize when bb
+// runs out of readable bytes.
+func (m *Header) DecodeFrom(bb buf.ByteBuf) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r == buf.ErrInsufficientSize || r == buf.ErrCompositeOutOfRange {
+				err = buf.ErrInsufficientSize
+				return
+			}
+			panic(r)
+		}
+	}()
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	m.Magic = bb.ReadUInt16()
+	m.Kind = Kind(bb.MustReadByte())
+	{
+		b := bb.ReadBytes(3)
+		v := uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16
+		m.Length = uint32(v)
+	}
+	return nil
+}
+
+// EncodedSize returns the number of bytes EncodeTo writes for m.
+func (m *Header) EncodedSize() int {
+	return 6
+}
+
+// EncodeTo writes m to bb. It panics with buf.ErrValueOverflow when a
+// value does not fit its wire width.
+func (m *Message) EncodeTo(bb buf.ByteBuf) {
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	m.Header.EncodeTo(bb)
+	buf.WriteVarInt64(bb, m.ID)
+	buf.WriteVarUInt64(bb, uint64(m.Seq))
+	if m.Delta < -32768 || m.Delta > 32767 {
+		panic(buf.ErrValueOverflow)
+	}
+	bb.WriteUInt16(uint16(m.Delta))
+	bb.WriteFloat64LE(m.Ratio)
+	if len(m.Name) > 65535 {
+		panic(buf.ErrValueOverflow)
+	}
+	bb.WriteUInt16(uint16(len(m.Name)))
+	bb.WriteString(m.Name)
+	buf.WriteVarUInt64(bb, uint64(len(m.Payload)))
+	bb.WriteBytes(m.Payload)
+	if len(m.Ports) > 255 {
+		panic(buf.ErrValueOverflow)
+	}
+	bb.AppendByte(byte(len(m.Ports)))
+	for i1 := range m.Ports {
+		bb.WriteUInt16LE(m.Ports[i1])
+	}
+	for range 4 {
+		bb.AppendByte(0)
+	}
+	bb.WriteBytes(m.Reserved[:])
+	for i2 := range m.Matrix {
+		bb.WriteUInt16(uint16(m.Matrix[i2]))
+	}
+	if m.Enabled {
+		bb.AppendByte(1)
+	} else {
+		bb.AppendByte(0)
+	}
+	if len(m.Tags) > 255 {
+		panic(buf.ErrValueOverflow)
+	}
+	bb.AppendByte(byte(len(m.Tags)))
+	for i3 := range m.Tags {
+		m.Tags[i3].EncodeTo(bb)
+	}
+}
+
+// DecodeFrom reads m from bb. It returns buf.ErrInsufficientSize when bb
+// runs out of readable bytes.
+func (m *Message) DecodeFrom(bb buf.ByteBuf) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r == buf.ErrInsufficientSize || r == buf.ErrCompositeOutOfRange {
+				err = buf.ErrInsufficientSize
+				return
+			}
+			panic(r)
+		}
+	}()
+	if o, ok := bb.(buf.OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	if err := m.Header.DecodeFrom(bb); err != nil {
+		return err
+	}
+	{
+		v, err := buf.ReadVarInt64(bb)
+		if err != nil {
+			return err
+		}
+		m.ID = v
+	}
+	{
+		v, err := buf.ReadVarUInt64(bb)
+		if err != nil {
+			return err
+		}
+		if uint64(uint32(v)) != v {
+			return buf.ErrValueOverflow
+		}
+		m.Seq = uint32(v)
+	}
+	m.Delta = int32(bb.ReadInt16())
+	m.Ratio = bb.ReadFloat64LE()
+	{
+		n := uint64(bb.ReadUInt16())
+		if n > uint64(bb.ReadableBytes()) {
+			return buf.ErrInsufficientSize
+		}
+		m.Name = string(bb.ReadBytes(int(n)))
+	}
+	{
+		n, err := buf.ReadVarUInt64(bb)
+		if err != nil {
+			return err
+		}
+		if n > uint64(bb.ReadableBytes()) {
+			return buf.ErrInsufficientSize
+		}
+		m.Payload = append([]byte(nil), bb.ReadBytes(int(n))...)
+	}
+	{
+		n := uint64(bb.MustReadByte())
+		if n > uint64(bb.ReadableBytes())/2 {
+			return buf.ErrInsufficientSize
+		}
+		m.Ports = make([]uint16, n)
+	}
+	for i1 := range m.Ports {
+		m.Ports[i1] = bb.ReadUInt16LE()
+	}
+	bb.Skip(4)
+	copy(m.Reserved[:], bb.ReadBytes(len(m.Reserved)))
+	for i2 := range m.Matrix {
+		m.Matrix[i2] = bb.ReadInt16()
+	}
+	m.Enabled = bb.MustReadByte() != 0
+	{
+		n := uint64(bb.MustReadByte())
+		if n > uint64(bb.ReadableBytes())/uint64(max(new(Header).EncodedSize(), 1)) {
+			return buf.ErrInsufficientSize
+		}
+		m.Tags = make([]Header, n)
+	}
+	for i3 := range m.Tags {
+		if err := m.Tags[i3].DecodeFrom(bb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodedSize returns the number of bytes EncodeTo writes for m.
+func (m *Message) EncodedSize() int {
+	n := 19
+	n += m.Header.EncodedSize()
+	n += buf.VarintLenZigZag(m.ID)
+	n += buf.VarintLen(uint64(m.Seq))
+	n += len(m.Name)
+	n += len(m.Payload)
+	n += buf.VarintLen(uint64(len(m.Payload)))
+	n += len(m.Ports) * 2
+	n += len(m.Reserved)
+	n += len(m.Matrix) * 2
+	for i1 := range m.Tags {
+		n += m.Tags[i1].EncodedSize()
+	}
+	return n
+}
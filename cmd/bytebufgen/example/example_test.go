@@ -0,0 +1,160 @@
+package example
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	buf "github.com/yetiz-org/goth-bytebuf"
+)
+
+func sampleMessage() Message {
+	return Message{
+		Header:   Header{Magic: 0xCAFE, Kind: 3, Length: 0x010203},
+		ID:       -42,
+		Seq:      300,
+		Delta:    -1000,
+		Ratio:    1.25,
+		Name:     "frame",
+		Payload:  []byte{9, 8, 7},
+		Ports:    []uint16{80, 443},
+		Reserved: [2]byte{0xAA, 0xBB},
+		Matrix:   [2]int16{-1, 2},
+		Enabled:  true,
+		Tags:     []Header{{Magic: 1}, {Kind: 2, Length: 7}},
+		Note:     "ignored",
+	}
+}
+
+// Generated methods produce the same bytes as buf.Marshal and round trip.
+func TestMessage_RoundTrip(t *testing.T) {
+	in := sampleMessage()
+	b := buf.EmptyByteBuf()
+	in.EncodeTo(b)
+	assert.Equal(t, in.EncodedSize(), b.ReadableBytes())
+
+	ref := buf.EmptyByteBuf()
+	assert.NoError(t, buf.Marshal(ref, &in))
+	assert.Equal(t, ref.Bytes(), b.Bytes())
+
+	var out Message
+	assert.NoError(t, out.DecodeFrom(b))
+	in.Note = ""
+	assert.Equal(t, in, out)
+	assert.Equal(t, 0, b.ReadableBytes())
+}
+
+// A little-endian Order view does not flip big-endian fields.
+func TestMessage_OrderedView(t *testing.T) {
+	in := sampleMessage()
+	ref := buf.EmptyByteBuf()
+	in.EncodeTo(ref)
+	le := buf.Order(buf.EmptyByteBuf(), binary.LittleEndian)
+	in.EncodeTo(le)
+	assert.Equal(t, ref.Bytes(), le.Bytes())
+
+	var out Message
+	assert.NoError(t, out.DecodeFrom(le))
+	in.Note = ""
+	assert.Equal(t, in, out)
+}
+
+// Decoding works across composite component boundaries.
+func TestMessage_DecodeComposite(t *testing.T) {
+	in := sampleMessage()
+	src := buf.EmptyByteBuf()
+	in.EncodeTo(src)
+	bs := src.Bytes()
+	c := buf.NewCompositeByteBuf(buf.NewByteBuf(bs[:5]), buf.NewByteBuf(bs[5:11]), buf.NewByteBuf(bs[11:]))
+
+	var out Message
+	assert.NoError(t, out.DecodeFrom(c))
+	assert.Equal(t, in.Tags, out.Tags)
+	assert.Equal(t, in.Name, out.Name)
+}
+
+// Truncated input and oversized values are reported as errors.
+func TestMessage_Errors(t *testing.T) {
+	in := sampleMessage()
+	b := buf.EmptyByteBuf()
+	in.EncodeTo(b)
+	bs := b.Bytes()
+
+	var out Message
+	assert.ErrorIs(t, out.DecodeFrom(buf.NewByteBuf(bs[:len(bs)-1])), buf.ErrInsufficientSize)
+	assert.ErrorIs(t, out.DecodeFrom(buf.NewByteBuf(bs[:3])), buf.ErrInsufficientSize)
+
+	assert.PanicsWithValue(t, buf.ErrValueOverflow, func() {
+		h := Header{Length: 1 << 24}
+		h.EncodeTo(buf.EmptyByteBuf())
+	})
+	assert.PanicsWithValue(t, buf.ErrValueOverflow, func() {
+		m := Message{Delta: 1 << 15}
+		m.EncodeTo(buf.EmptyByteBuf())
+	})
+
+	// A slice count is checked against its element size: three Ports need
+	// six bytes, so four readable bytes fail before any is read.
+	ports := buf.EmptyByteBuf()
+	(&Message{Ports: []uint16{1, 2, 3}}).EncodeTo(ports)
+	countAt := ports.ReadableBytes() - 12 - 6 - 1 // reserved, matrix, enabled, tags
+	trunc := buf.NewByteBuf(ports.Bytes()[:countAt+1+4])
+	assert.ErrorIs(t, out.DecodeFrom(trunc), buf.ErrInsufficientSize)
+	assert.Equal(t, 4, trunc.ReadableBytes())
+}
+
+// A ByteBuf from outside the package, without buf.VarintCodec, gets the
+// same byte-wise varints as buf.Marshal and buf.Unmarshal use.
+func TestMessage_ForeignByteBufParity(t *testing.T) {
+	in := sampleMessage()
+	foreign := struct{ buf.ByteBuf }{buf.EmptyByteBuf()}
+	_, isVarint := any(foreign).(buf.VarintCodec)
+	assert.False(t, isVarint)
+	in.EncodeTo(foreign)
+
+	ref := struct{ buf.ByteBuf }{buf.EmptyByteBuf()}
+	assert.NoError(t, buf.Marshal(ref, &in))
+	assert.Equal(t, ref.Bytes(), foreign.Bytes())
+
+	var out, refOut Message
+	assert.NoError(t, out.DecodeFrom(foreign))
+	assert.NoError(t, buf.Unmarshal(ref, &refOut))
+	in.Note = ""
+	assert.Equal(t, in, out)
+	assert.Equal(t, in, refOut)
+}
+
+// Embedded structs are encoded inline and unexported fields are skipped,
+// as buf.Marshal does.
+func TestEnvelope_RoundTrip(t *testing.T) {
+	in := Envelope{Header: Header{Magic: 0xCAFE, Kind: 3, Length: 9}, Body: 0x0102}
+	b := buf.EmptyByteBuf()
+	in.EncodeTo(b)
+	assert.Equal(t, 8, in.EncodedSize())
+
+	ref := buf.EmptyByteBuf()
+	assert.NoError(t, buf.Marshal(ref, &in))
+	assert.Equal(t, ref.Bytes(), b.Bytes())
+
+	var out Envelope
+	assert.NoError(t, out.DecodeFrom(b))
+	assert.Equal(t, in.Header, out.Header)
+	assert.Equal(t, in.Body, out.Body)
+}
+
+// Slices and arrays of a named byte type match buf.Marshal and round trip.
+func TestFlags_RoundTrip(t *testing.T) {
+	in := Flags{Kinds: []Kind{1, 2}, Mask: [3]Kind{7, 8, 9}}
+	b := buf.EmptyByteBuf()
+	in.EncodeTo(b)
+	assert.Equal(t, []byte{2, 1, 2, 7, 8, 9}, b.Bytes())
+	assert.Equal(t, in.EncodedSize(), b.ReadableBytes())
+
+	ref := buf.EmptyByteBuf()
+	assert.NoError(t, buf.Marshal(ref, &in))
+	assert.Equal(t, ref.Bytes(), b.Bytes())
+
+	var out Flags
+	assert.NoError(t, out.DecodeFrom(b))
+	assert.Equal(t, in, out)
+}
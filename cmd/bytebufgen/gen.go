@@ -0,0 +1,916 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	generatedMarker = "Code generated by bytebufgen"
+	generateMarker  = "bytebuf:generate"
+	importPath      = "github.com/yetiz-org/goth-bytebuf"
+	varintWidth     = -1
+)
+
+var (
+	errNoTypes     = errors.New("no struct types selected")
+	errUnsupported = errors.New("unsupported field type")
+	errInvalidTag  = errors.New("invalid bytebuf tag")
+)
+
+// pkgInfo is the syntactic view of a package needed for generation.
+type pkgInfo struct {
+	name   string
+	specs  map[string]*ast.TypeSpec
+	marked []string
+	fset   *token.FileSet
+}
+
+func loadPackage(dir, output string) (*pkgInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &pkgInfo{specs: map[string]*ast.TypeSpec{}, fset: token.NewFileSet()}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		if output != "" && filepath.Base(output) == name {
+			continue
+		}
+		f, err := parser.ParseFile(pkg.fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if isOwnOutput(f) {
+			continue
+		}
+		if pkg.name == "" {
+			pkg.name = f.Name.Name
+		}
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				pkg.specs[ts.Name.Name] = ts
+				if _, isStruct := ts.Type.(*ast.StructType); !isStruct {
+					continue
+				}
+				if hasMarker(ts.Doc) || len(gd.Specs) == 1 && hasMarker(gd.Doc) {
+					pkg.marked = append(pkg.marked, ts.Name.Name)
+				}
+			}
+		}
+	}
+	if pkg.name == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return pkg, nil
+}
+
+func isOwnOutput(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() >= f.Package {
+			break
+		}
+		if strings.Contains(cg.Text(), generatedMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMarker(cg *ast.CommentGroup) bool {
+	if cg == nil {
+		return false
+	}
+	for _, c := range cg.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == generateMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// generate renders the methods for the selected types as formatted Go
+// source.
+func generate(pkg *pkgInfo, types []string) ([]byte, error) {
+	if len(types) == 0 {
+		types = pkg.marked
+	}
+	if len(types) == 0 {
+		return nil, errNoTypes
+	}
+	sort.Strings(types)
+
+	g := &generator{pkg: pkg}
+	fmt.Fprintf(&g.buf, "// %s. DO NOT EDIT.\n\n", generatedMarker)
+	fmt.Fprintf(&g.buf, "package %s\n\n", pkg.name)
+	fmt.Fprintf(&g.buf, "import buf %q\n", importPath)
+	for _, name := range types {
+		ts, ok := pkg.specs[name]
+		if !ok {
+			return nil, fmt.Errorf("type %s not found", name)
+		}
+		st, ok := ts.Type.(*ast.StructType)
+		if !ok {
+			return nil, fmt.Errorf("type %s is not a struct", name)
+		}
+		if err := g.genType(name, st); err != nil {
+			return nil, err
+		}
+	}
+	return format.Source(g.buf.Bytes())
+}
+
+type kind int
+
+const (
+	kindBool kind = iota
+	kindInt
+	kindUint
+	kindFloat
+	kindString
+	kindBytes
+	kindSlice
+	kindArray
+	kindStruct
+)
+
+// fieldType is the resolved wire shape of a Go type expression.
+type fieldType struct {
+	kind     kind
+	size     int    // natural size in bytes for bool, integer and float kinds
+	typeText string // Go source of the declared type, used for conversions
+	elem     *fieldType
+}
+
+// wireOpts is the parsed form of a bytebuf struct tag; it mirrors the tag
+// grammar accepted by buf.Marshal.
+type wireOpts struct {
+	width    int
+	signed   bool
+	le       bool
+	varint   bool
+	lenWidth int
+	skip     int
+}
+
+type field struct {
+	name string
+	typ  *fieldType
+	opts wireOpts
+}
+
+type generator struct {
+	pkg   *pkgInfo
+	buf   bytes.Buffer
+	depth int
+}
+
+func (g *generator) genType(name string, st *ast.StructType) error {
+	var fields []field
+	for _, f := range st.Fields.List {
+		tag := ""
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(raw).Get("bytebuf")
+		}
+		if tag == "-" {
+			continue
+		}
+		// Like buf.Marshal, skip unexported fields before looking at their
+		// types, and encode an embedded struct as a field named after its
+		// type.
+		var names []string
+		for _, n := range fieldNames(f) {
+			if ast.IsExported(n) {
+				names = append(names, n)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		where := name + "." + names[0]
+		opts, err := parseTag(tag)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, where)
+		}
+		ft, err := g.resolve(f.Type)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, where)
+		}
+		if err := validate(ft, opts); err != nil {
+			return fmt.Errorf("%w: %s", err, where)
+		}
+		for _, n := range names {
+			fields = append(fields, field{name: n, typ: ft, opts: opts})
+		}
+	}
+
+	g.printf("\n// EncodeTo writes m to bb. It panics with buf.ErrValueOverflow when a\n")
+	g.printf("// value does not fit its wire width.\n")
+	g.printf("func (m *%s) EncodeTo(bb buf.ByteBuf) {\n", name)
+	g.depth = 0
+	// Unsuffixed accessors are big-endian only on an unordered buffer.
+	g.printf("if o, ok := bb.(buf.OrderedByteBuf); ok {\nbb = o.Unwrap()\n}\n")
+	for _, f := range fields {
+		if f.opts.skip > 0 {
+			g.printf("for range %d {\nbb.AppendByte(0)\n}\n", f.opts.skip)
+		}
+		g.encode("m."+f.name, f.typ, f.opts)
+	}
+	g.printf("}\n")
+
+	g.printf("\n// DecodeFrom reads m from bb. It returns buf.ErrInsufficientSize when bb\n")
+	g.printf("// runs out of readable bytes.\n")
+	g.printf("func (m *%s) DecodeFrom(bb buf.ByteBuf) (err error) {\n", name)
+	g.depth = 0
+	g.printf("defer func() {\nif r := recover(); r != nil {\n")
+	g.printf("if r == buf.ErrInsufficientSize || r == buf.ErrCompositeOutOfRange {\nerr = buf.ErrInsufficientSize\nreturn\n}\n")
+	g.printf("panic(r)\n}\n}()\n")
+	g.printf("if o, ok := bb.(buf.OrderedByteBuf); ok {\nbb = o.Unwrap()\n}\n")
+	for _, f := range fields {
+		if f.opts.skip > 0 {
+			g.printf("bb.Skip(%d)\n", f.opts.skip)
+		}
+		g.decode("m."+f.name, f.typ, f.opts)
+	}
+	g.printf("return nil\n}\n")
+
+	g.printf("\n// EncodedSize returns the number of bytes EncodeTo writes for m.\n")
+	g.printf("func (m *%s) EncodedSize() int {\n", name)
+	g.depth = 0
+	body := g.buf.Len()
+	fixed := 0
+	for _, f := range fields {
+		fixed += f.opts.skip + g.size("m."+f.name, f.typ, f.opts)
+	}
+	if g.buf.Len() == body {
+		g.printf("return %d\n}\n", fixed)
+		return nil
+	}
+	dynamic := append([]byte(nil), g.buf.Bytes()[body:]...)
+	g.buf.Truncate(body)
+	g.printf("n := %d\n%sreturn n\n}\n", fixed, dynamic)
+	return nil
+}
+
+// fieldNames returns the names f declares; an embedded field is named
+// after its type.
+func fieldNames(f *ast.Field) []string {
+	if len(f.Names) == 0 {
+		t := f.Type
+		if star, ok := t.(*ast.StarExpr); ok {
+			t = star.X
+		}
+		switch t := t.(type) {
+		case *ast.Ident:
+			return []string{t.Name}
+		case *ast.SelectorExpr:
+			return []string{t.Sel.Name}
+		}
+		return nil
+	}
+	names := make([]string, len(f.Names))
+	for i, n := range f.Names {
+		names[i] = n.Name
+	}
+	return names
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// loopVar returns a fresh index variable name for nested loops.
+func (g *generator) loopVar() string {
+	g.depth++
+	return "i" + strconv.Itoa(g.depth)
+}
+
+var builtinSizes = map[string]struct {
+	kind kind
+	size int
+}{
+	"bool":    {kindBool, 1},
+	"int8":    {kindInt, 1},
+	"int16":   {kindInt, 2},
+	"int32":   {kindInt, 4},
+	"rune":    {kindInt, 4},
+	"int64":   {kindInt, 8},
+	"int":     {kindInt, 8},
+	"uint8":   {kindUint, 1},
+	"byte":    {kindUint, 1},
+	"uint16":  {kindUint, 2},
+	"uint32":  {kindUint, 4},
+	"uint64":  {kindUint, 8},
+	"uint":    {kindUint, 8},
+	"uintptr": {kindUint, 8},
+	"float32": {kindFloat, 4},
+	"float64": {kindFloat, 8},
+	"string":  {kindString, 0},
+}
+
+func (g *generator) resolve(expr ast.Expr) (*fieldType, error) {
+	text := g.exprText(expr)
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if b, ok := builtinSizes[e.Name]; ok {
+			return &fieldType{kind: b.kind, size: b.size, typeText: text}, nil
+		}
+		ts, ok := g.pkg.specs[e.Name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", errUnsupported, e.Name)
+		}
+		if _, isStruct := ts.Type.(*ast.StructType); isStruct {
+			return &fieldType{kind: kindStruct, typeText: text}, nil
+		}
+		under, err := g.resolve(ts.Type)
+		if err != nil {
+			return nil, err
+		}
+		named := *under
+		named.typeText = text
+		return &named, nil
+	case *ast.ArrayType:
+		elem, err := g.resolve(e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if e.Len == nil {
+			if isByte(elem) {
+				return &fieldType{kind: kindBytes, typeText: text, elem: elem}, nil
+			}
+			return &fieldType{kind: kindSlice, typeText: text, elem: elem}, nil
+		}
+		return &fieldType{kind: kindArray, typeText: text, elem: elem}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errUnsupported, text)
+}
+
+func (g *generator) exprText(expr ast.Expr) string {
+	var b bytes.Buffer
+	_ = format.Node(&b, g.pkg.fset, expr)
+	return b.String()
+}
+
+func validate(ft *fieldType, o wireOpts) error {
+	switch ft.kind {
+	case kindBool:
+		if o.varint || o.lenWidth != 0 || o.width > 1 {
+			return errInvalidTag
+		}
+	case kindInt, kindUint:
+		if o.lenWidth != 0 || o.varint && o.width != 0 {
+			return errInvalidTag
+		}
+	case kindFloat:
+		if o.varint || o.lenWidth != 0 || o.width != 0 {
+			return errInvalidTag
+		}
+	case kindString, kindBytes:
+		if o.lenWidth == 0 || o.width != 0 || o.varint {
+			return errInvalidTag
+		}
+	case kindSlice:
+		if o.lenWidth == 0 {
+			return errInvalidTag
+		}
+		elem := o
+		elem.lenWidth, elem.skip = 0, 0
+		return validate(ft.elem, elem)
+	case kindArray:
+		if o.lenWidth != 0 {
+			return errInvalidTag
+		}
+		if isByte(ft.elem) && o.width == 0 && !o.varint {
+			return nil
+		}
+		elem := o
+		elem.skip = 0
+		return validate(ft.elem, elem)
+	case kindStruct:
+		if o.lenWidth != 0 || o.width != 0 || o.varint {
+			return errInvalidTag
+		}
+	}
+	return nil
+}
+
+// isByte reports whether ft is byte or uint8 itself. Slices and arrays of
+// other one-byte types, named ones included, go element by element, since
+// they do not convert to []byte.
+func isByte(ft *fieldType) bool {
+	return ft.typeText == "byte" || ft.typeText == "uint8"
+}
+
+func parseTag(tag string) (wireOpts, error) {
+	var o wireOpts
+	if tag == "" {
+		return o, nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case part == "le":
+			o.le = true
+		case part == "be":
+			o.le = false
+		case part == "varint":
+			o.varint = true
+		case strings.HasPrefix(part, "len="):
+			spec := strings.TrimPrefix(part, "len=")
+			if spec == "varint" {
+				o.lenWidth = varintWidth
+				continue
+			}
+			w, _, ok := parseWidth(spec)
+			if !ok {
+				return o, errInvalidTag
+			}
+			o.lenWidth = w
+		case strings.HasPrefix(part, "skip="):
+			n, err := strconv.Atoi(strings.TrimPrefix(part, "skip="))
+			if err != nil || n < 0 {
+				return o, errInvalidTag
+			}
+			o.skip = n
+		default:
+			w, signed, ok := parseWidth(part)
+			if !ok {
+				return o, errInvalidTag
+			}
+			o.width, o.signed = w, signed
+		}
+	}
+	return o, nil
+}
+
+func parseWidth(s string) (width int, signed bool, ok bool) {
+	if len(s) < 2 || (s[0] != 'u' && s[0] != 'i') {
+		return 0, false, false
+	}
+	switch s[1:] {
+	case "8":
+		width = 1
+	case "16":
+		width = 2
+	case "24":
+		width = 3
+	case "32":
+		width = 4
+	case "64":
+		width = 8
+	default:
+		return 0, false, false
+	}
+	return width, s[0] == 'i', true
+}
+
+// ---------- encode ----------
+
+func (g *generator) encode(x string, ft *fieldType, o wireOpts) {
+	switch ft.kind {
+	case kindBool:
+		g.printf("if %s {\nbb.AppendByte(1)\n} else {\nbb.AppendByte(0)\n}\n", x)
+	case kindInt, kindUint:
+		g.encodeInt(x, ft, o)
+	case kindFloat:
+		g.printf("bb.WriteFloat%d%s(%s)\n", ft.size*8, leSuffix(o.le), convert(fmt.Sprintf("float%d", ft.size*8), ft.typeText, x))
+	case kindString:
+		g.encodeLength("len("+x+")", o)
+		g.printf("bb.WriteString(%s)\n", convert("string", ft.typeText, x))
+	case kindBytes:
+		g.encodeLength("len("+x+")", o)
+		g.printf("bb.WriteBytes(%s)\n", x)
+	case kindSlice:
+		g.encodeLength("len("+x+")", o)
+		i := g.loopVar()
+		elem := o
+		elem.lenWidth, elem.skip = 0, 0
+		g.printf("for %s := range %s {\n", i, x)
+		g.encode(x+"["+i+"]", ft.elem, elem)
+		g.printf("}\n")
+	case kindArray:
+		if isByte(ft.elem) && o.width == 0 && !o.varint {
+			g.printf("bb.WriteBytes(%s[:])\n", x)
+			return
+		}
+		i := g.loopVar()
+		elem := o
+		elem.skip = 0
+		g.printf("for %s := range %s {\n", i, x)
+		g.encode(x+"["+i+"]", ft.elem, elem)
+		g.printf("}\n")
+	case kindStruct:
+		g.printf("%s.EncodeTo(bb)\n", x)
+	}
+}
+
+func (g *generator) encodeInt(x string, ft *fieldType, o wireOpts) {
+	signedField := ft.kind == kindInt
+	if o.varint {
+		if signedField {
+			g.printf("buf.WriteVarInt64(bb, %s)\n", convert("int64", ft.typeText, x))
+		} else {
+			g.printf("buf.WriteVarUInt64(bb, %s)\n", convert("uint64", ft.typeText, x))
+		}
+		return
+	}
+	width, signedWire := o.width, o.signed
+	if width == 0 {
+		width, signedWire = ft.size, signedField
+	}
+	if !fitsRange(ft.size, signedField, width, signedWire) {
+		g.overflowCheck(x, signedField, width, signedWire)
+	}
+	g.writeUint(x, ft.typeText, width, o.le)
+}
+
+// fitsRange reports whether every value of a from-sized integer fits a
+// to-sized integer of the given signedness.
+func fitsRange(fromSize int, fromSigned bool, toSize int, toSigned bool) bool {
+	switch {
+	case fromSigned == toSigned:
+		return fromSize <= toSize
+	case toSigned:
+		return fromSize < toSize
+	default:
+		return false
+	}
+}
+
+// overflowCheck emits a panic when x does not fit the wire width.
+func (g *generator) overflowCheck(x string, signedField bool, width int, signedWire bool) {
+	var cond string
+	switch {
+	case signedField && signedWire:
+		lim := int64(1) << (8*width - 1)
+		cond = fmt.Sprintf("%s < %d || %s > %d", x, -lim, x, lim-1)
+	case signedField:
+		cond = fmt.Sprintf("%s < 0", x)
+		if width < 8 {
+			cond += fmt.Sprintf(" || uint64(%s) > %d", x, uint64(1)<<(8*width)-1)
+		}
+	case signedWire:
+		cond = fmt.Sprintf("uint64(%s) > %d", x, uint64(1)<<(8*width-1)-1)
+	default:
+		cond = fmt.Sprintf("uint64(%s) > %d", x, uint64(1)<<(8*width)-1)
+	}
+	g.printf("if %s {\npanic(buf.ErrValueOverflow)\n}\n", cond)
+}
+
+// writeUint writes the low width bytes of the integer expression x of type t.
+func (g *generator) writeUint(x, t string, width int, le bool) {
+	switch width {
+	case 1:
+		g.printf("bb.AppendByte(%s)\n", convert("byte", t, x))
+	case 3:
+		if le {
+			g.printf("bb.AppendByte(byte(%s))\nbb.AppendByte(byte(%s >> 8))\nbb.AppendByte(byte(%s >> 16))\n", x, x, x)
+		} else {
+			g.printf("bb.AppendByte(byte(%s >> 16))\nbb.AppendByte(byte(%s >> 8))\nbb.AppendByte(byte(%s))\n", x, x, x)
+		}
+	default:
+		g.printf("bb.WriteUInt%d%s(%s)\n", width*8, leSuffix(le), convert(fmt.Sprintf("uint%d", width*8), t, x))
+	}
+}
+
+func (g *generator) encodeLength(n string, o wireOpts) {
+	if o.lenWidth == varintWidth {
+		g.printf("buf.WriteVarUInt64(bb, uint64(%s))\n", n)
+		return
+	}
+	if o.lenWidth < 8 {
+		g.printf("if %s > %d {\npanic(buf.ErrValueOverflow)\n}\n", n, uint64(1)<<(8*o.lenWidth)-1)
+	}
+	g.writeUint(n, "int", o.lenWidth, o.le)
+}
+
+// ---------- decode ----------
+
+func (g *generator) decode(x string, ft *fieldType, o wireOpts) {
+	switch ft.kind {
+	case kindBool:
+		g.printf("%s = %s\n", x, convert(ft.typeText, "bool", "bb.MustReadByte() != 0"))
+	case kindInt, kindUint:
+		g.decodeInt(x, ft, o)
+	case kindFloat:
+		from := fmt.Sprintf("float%d", ft.size*8)
+		g.printf("%s = %s\n", x, convert(ft.typeText, from, fmt.Sprintf("bb.ReadFloat%d%s()", ft.size*8, leSuffix(o.le))))
+	case kindString:
+		g.printf("{\n")
+		g.decodeLength(o, "1")
+		g.printf("%s = %s(bb.ReadBytes(int(n)))\n}\n", x, ft.typeText)
+	case kindBytes:
+		g.printf("{\n")
+		g.decodeLength(o, "1")
+		g.printf("%s = append(%s(nil), bb.ReadBytes(int(n))...)\n}\n", x, ft.typeText)
+	case kindSlice:
+		elem := o
+		elem.lenWidth, elem.skip = 0, 0
+		g.printf("{\n")
+		g.decodeLength(o, minSize(ft.elem, elem))
+		g.printf("%s = make(%s, n)\n}\n", x, ft.typeText)
+		i := g.loopVar()
+		g.printf("for %s := range %s {\n", i, x)
+		g.decode(x+"["+i+"]", ft.elem, elem)
+		g.printf("}\n")
+	case kindArray:
+		if isByte(ft.elem) && o.width == 0 && !o.varint {
+			g.printf("copy(%s[:], bb.ReadBytes(len(%s)))\n", x, x)
+			return
+		}
+		i := g.loopVar()
+		elem := o
+		elem.skip = 0
+		g.printf("for %s := range %s {\n", i, x)
+		g.decode(x+"["+i+"]", ft.elem, elem)
+		g.printf("}\n")
+	case kindStruct:
+		g.printf("if err := %s.DecodeFrom(bb); err != nil {\nreturn err\n}\n", x)
+	}
+}
+
+func (g *generator) decodeInt(x string, ft *fieldType, o wireOpts) {
+	signedField := ft.kind == kindInt
+	t := ft.typeText
+	if o.varint {
+		g.printf("{\n")
+		if signedField {
+			g.printf("v, err := buf.ReadVarInt64(bb)\nif err != nil {\nreturn err\n}\n")
+		} else {
+			g.printf("v, err := buf.ReadVarUInt64(bb)\nif err != nil {\nreturn err\n}\n")
+		}
+		if !fitsRange(8, signedField, ft.size, signedField) {
+			g.rangeCheck(t, signedField, signedField)
+		}
+		g.printf("%s = %s\n}\n", x, convert(t, wireType(8, signedField), "v"))
+		return
+	}
+	width, signedWire := o.width, o.signed
+	if width == 0 {
+		width, signedWire = ft.size, signedField
+	}
+	if width != 3 && width == ft.size && signedWire == signedField {
+		g.printf("%s = %s\n", x, convert(t, wireType(width, signedWire), readExpr(width, o.le, signedWire)))
+		return
+	}
+	if fitsRange(width, signedWire, ft.size, signedField) && width != 3 {
+		g.printf("%s = %s(%s)\n", x, t, readExpr(width, o.le, signedWire))
+		return
+	}
+	g.printf("{\n")
+	switch {
+	case !signedWire:
+		g.readUint("v", width, o.le)
+	case width != 3:
+		g.printf("v := int64(%s)\n", readExpr(width, o.le, true))
+	default:
+		g.readUint("u", width, o.le)
+		g.printf("v := int64(u<<40) >> 40\n")
+	}
+	if !fitsRange(width, signedWire, ft.size, signedField) {
+		g.rangeCheck(t, signedWire, signedField)
+	}
+	g.printf("%s = %s(v)\n}\n", x, t)
+}
+
+// wireType returns the Go type a width-byte ByteBuf reader returns.
+func wireType(width int, signed bool) string {
+	if signed {
+		return fmt.Sprintf("int%d", width*8)
+	}
+	return fmt.Sprintf("uint%d", width*8)
+}
+
+// convert returns expr converted from type from to type to, omitting the
+// conversion when the two are the same builtin type.
+func convert(to, from, expr string) string {
+	canonical := map[string]string{"byte": "uint8", "rune": "int32"}
+	a, b := to, from
+	if c, ok := canonical[a]; ok {
+		a = c
+	}
+	if c, ok := canonical[b]; ok {
+		b = c
+	}
+	if a == b {
+		return expr
+	}
+	return to + "(" + expr + ")"
+}
+
+// rangeCheck emits a check that v converts to t without loss.
+func (g *generator) rangeCheck(t string, signedWire, signedField bool) {
+	switch {
+	case signedWire && signedField:
+		g.printf("if int64(%s(v)) != v {\nreturn buf.ErrValueOverflow\n}\n", t)
+	case signedWire:
+		g.printf("if v < 0 || uint64(%s(v)) != uint64(v) {\nreturn buf.ErrValueOverflow\n}\n", t)
+	case signedField:
+		g.printf("if %s(v) < 0 || uint64(%s(v)) != v {\nreturn buf.ErrValueOverflow\n}\n", t, t)
+	default:
+		g.printf("if uint64(%s(v)) != v {\nreturn buf.ErrValueOverflow\n}\n", t)
+	}
+}
+
+// readUint emits statements declaring name as the uint64 value of a
+// width-byte unsigned integer.
+func (g *generator) readUint(name string, width int, le bool) {
+	if width != 3 {
+		g.printf("%s := uint64(%s)\n", name, readExpr(width, le, false))
+		return
+	}
+	g.printf("b := bb.ReadBytes(3)\n")
+	if le {
+		g.printf("%s := uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16\n", name)
+	} else {
+		g.printf("%s := uint64(b[0])<<16 | uint64(b[1])<<8 | uint64(b[2])\n", name)
+	}
+}
+
+// readExpr returns an expression reading a width-byte integer; width must
+// not be 3.
+func readExpr(width int, le, signed bool) string {
+	if width == 1 {
+		if signed {
+			return "int8(bb.MustReadByte())"
+		}
+		return "bb.MustReadByte()"
+	}
+	prefix := "UInt"
+	if signed {
+		prefix = "Int"
+	}
+	return fmt.Sprintf("bb.Read%s%d%s()", prefix, width*8, leSuffix(le))
+}
+
+// decodeLength emits statements declaring n as the length prefix and
+// rejecting counts whose elements, at unit bytes each at least, cannot fit
+// the readable bytes, so a hostile prefix cannot force a huge allocation.
+// unit is a Go expression; "" and "1" check bytes.
+func (g *generator) decodeLength(o wireOpts, unit string) {
+	if o.lenWidth == varintWidth {
+		g.printf("n, err := buf.ReadVarUInt64(bb)\nif err != nil {\nreturn err\n}\n")
+	} else {
+		g.readUint("n", o.lenWidth, o.le)
+	}
+	_, err := strconv.Atoi(unit)
+	switch {
+	case unit == "" || unit == "1":
+		g.printf("if n > uint64(bb.ReadableBytes()) {\n")
+	case err == nil:
+		g.printf("if n > uint64(bb.ReadableBytes())/%s {\n", unit)
+	default:
+		g.printf("if n > uint64(bb.ReadableBytes())/uint64(max(%s, 1)) {\n", unit)
+	}
+	g.printf("return buf.ErrInsufficientSize\n}\n")
+}
+
+// minSize returns a Go expression for the fewest bytes a value of ft
+// encodes to, or "" when that may be none.
+func minSize(ft *fieldType, o wireOpts) string {
+	if ft.kind == kindArray {
+		if n, ok := fixedSize(ft, o); ok {
+			if n == 1 {
+				return fmt.Sprintf("len(%s{})", ft.typeText)
+			}
+			return fmt.Sprintf("%d*len(%s{})", n, ft.typeText)
+		}
+		return ""
+	}
+	if n, ok := fixedSize(ft, o); ok {
+		return strconv.Itoa(n)
+	}
+	switch ft.kind {
+	case kindInt, kindUint:
+		return "1"
+	case kindString, kindBytes, kindSlice:
+		if o.lenWidth == varintWidth {
+			return "1"
+		}
+		if o.lenWidth > 0 {
+			return strconv.Itoa(o.lenWidth)
+		}
+	case kindStruct:
+		return fmt.Sprintf("new(%s).EncodedSize()", ft.typeText)
+	}
+	return ""
+}
+
+// ---------- size ----------
+
+// size emits statements adding the value-dependent part of x's encoded size
+// to n and returns the constant part.
+func (g *generator) size(x string, ft *fieldType, o wireOpts) int {
+	if n, ok := fixedSize(ft, o); ok {
+		if ft.kind == kindArray {
+			g.sizeElems(x, n)
+			return 0
+		}
+		return n
+	}
+	switch ft.kind {
+	case kindInt:
+		g.printf("n += buf.VarintLenZigZag(%s)\n", convert("int64", ft.typeText, x))
+	case kindUint:
+		g.printf("n += buf.VarintLen(%s)\n", convert("uint64", ft.typeText, x))
+	case kindString, kindBytes:
+		g.printf("n += len(%s)\n", x)
+		return g.sizeLength(x, o)
+	case kindSlice, kindArray:
+		fixed := 0
+		if ft.kind == kindSlice {
+			fixed = g.sizeLength(x, o)
+		}
+		elem := o
+		elem.lenWidth, elem.skip = 0, 0
+		if en, ok := fixedSize(ft.elem, elem); ok {
+			g.sizeElems(x, en)
+			return fixed
+		}
+		i := g.loopVar()
+		g.printf("for %s := range %s {\n", i, x)
+		g.size(x+"["+i+"]", ft.elem, elem)
+		g.printf("}\n")
+		return fixed
+	case kindStruct:
+		g.printf("n += %s.EncodedSize()\n", x)
+	}
+	return 0
+}
+
+func (g *generator) sizeElems(x string, elemSize int) {
+	if elemSize == 1 {
+		g.printf("n += len(%s)\n", x)
+		return
+	}
+	g.printf("n += len(%s) * %d\n", x, elemSize)
+}
+
+// fixedSize returns the encoded size of ft when it does not depend on the
+// value. For arrays it returns the per-element size.
+func fixedSize(ft *fieldType, o wireOpts) (int, bool) {
+	switch ft.kind {
+	case kindBool:
+		return 1, true
+	case kindInt, kindUint:
+		if o.varint {
+			return 0, false
+		}
+		if o.width != 0 {
+			return o.width, true
+		}
+		return ft.size, true
+	case kindFloat:
+		return ft.size, true
+	case kindArray:
+		if isByte(ft.elem) && o.width == 0 && !o.varint {
+			return 1, true
+		}
+		elem := o
+		elem.skip = 0
+		if ft.elem.kind == kindArray {
+			return 0, false
+		}
+		return fixedSize(ft.elem, elem)
+	}
+	return 0, false
+}
+
+// sizeLength accounts for the length prefix of x, returning its width when
+// fixed.
+func (g *generator) sizeLength(x string, o wireOpts) int {
+	if o.lenWidth == varintWidth {
+		g.printf("n += buf.VarintLen(uint64(len(%s)))\n", x)
+		return 0
+	}
+	return o.lenWidth
+}
+
+func leSuffix(le bool) string {
+	if le {
+		return "LE"
+	}
+	return ""
+}
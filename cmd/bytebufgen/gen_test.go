@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The committed example output is up to date with the generator.
+func TestGenerate_Example(t *testing.T) {
+	pkg, err := loadPackage("example", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Header", "Message", "Envelope", "Flags"}, pkg.marked)
+
+	src, err := generate(pkg, nil)
+	assert.NoError(t, err)
+	want, err := os.ReadFile(filepath.Join("example", "example_bytebuf.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(src))
+}
+
+// Explicit -type selection, tag errors and unsupported types.
+func TestGenerate_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(src string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0o644))
+	}
+
+	write("package a\n\ntype T struct {\n\tV uint16 `bytebuf:\"u12\"`\n}\n")
+	err := run(dir, []string{"T"}, "")
+	assert.ErrorIs(t, err, errInvalidTag)
+	assert.Contains(t, err.Error(), "T.V")
+
+	write("package a\n\ntype T struct {\n\tS string\n}\n")
+	assert.ErrorIs(t, run(dir, []string{"T"}, ""), errInvalidTag)
+
+	write("package a\n\ntype T struct {\n\tM map[string]int\n}\n")
+	assert.ErrorIs(t, run(dir, []string{"T"}, ""), errUnsupported)
+
+	write("package a\n\ntype T struct {\n\tV uint8\n}\n")
+	assert.ErrorIs(t, run(dir, nil, ""), errNoTypes)
+	assert.NoError(t, run(dir, []string{"T"}, ""))
+	_, err = os.Stat(filepath.Join(dir, "a_bytebuf.go"))
+	assert.NoError(t, err)
+
+	// The previous output is ignored on regeneration.
+	assert.NoError(t, run(dir, []string{"T"}, ""))
+}
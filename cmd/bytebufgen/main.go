@@ -0,0 +1,71 @@
+// Command bytebufgen generates ByteBuf encode/decode methods for Go structs.
+//
+// It reads the non-test Go files of a package, selects struct types that are
+// listed with -type or carry a "//bytebuf:generate" doc comment, and writes
+// for each of them
+//
+//	func (m *T) EncodeTo(bb buf.ByteBuf)
+//	func (m *T) DecodeFrom(bb buf.ByteBuf) error
+//	func (m *T) EncodedSize() int
+//
+// The methods call the typed ByteBuf readers and writers directly and follow
+// the same `bytebuf` struct tags as buf.Marshal, so a type can switch from
+// reflection to generated code without changing its wire format. EncodeTo
+// panics with buf.ErrValueOverflow when a value does not fit its tagged
+// width; DecodeFrom returns buf.ErrInsufficientSize on truncated input.
+// Nested struct fields must have generated methods as well.
+//
+// Typical use:
+//
+//	//go:generate go run github.com/yetiz-org/goth-bytebuf/cmd/bytebufgen -type=Header,Message
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeList := flag.String("type", "", "comma-separated struct types to generate; defaults to types marked //bytebuf:generate")
+	output := flag.String("output", "", "output file name; defaults to <package>_bytebuf.go in the package directory")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: bytebufgen [-type T1,T2] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	var types []string
+	if *typeList != "" {
+		types = strings.Split(*typeList, ",")
+	}
+
+	if err := run(dir, types, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "bytebufgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(dir string, types []string, output string) error {
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		return err
+	}
+	src, err := generate(pkg, types)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		output = pkg.name + "_bytebuf.go"
+	}
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dir, output)
+	}
+	return os.WriteFile(output, src, 0o644)
+}
@@ -0,0 +1,111 @@
+package buf
+
+import (
+	"bytes"
+	"cmp"
+)
+
+// Equal reports whether the readable regions of a and b hold the same
+// bytes. Component layouts of composites need not match; neither buffer is
+// flattened and nothing is allocated.
+func Equal(a, b ByteBuf) bool {
+	if a == nil || b == nil {
+		panic(ErrNilObject)
+	}
+	n := a.ReadableBytes()
+	if n != b.ReadableBytes() {
+		return false
+	}
+	return compareRange(a, a.ReaderIndex(), b, b.ReaderIndex(), n) == 0
+}
+
+// Compare orders the readable regions of a and b lexicographically like
+// bytes.Compare, returning -1, 0 or +1.
+func Compare(a, b ByteBuf) int {
+	if a == nil || b == nil {
+		panic(ErrNilObject)
+	}
+	na, nb := a.ReadableBytes(), b.ReadableBytes()
+	if c := compareRange(a, a.ReaderIndex(), b, b.ReaderIndex(), min(na, nb)); c != 0 {
+		return c
+	}
+	return cmp.Compare(na, nb)
+}
+
+// HasPrefix reports whether the readable region of bb begins with the
+// readable region of prefix.
+func HasPrefix(bb, prefix ByteBuf) bool {
+	if bb == nil || prefix == nil {
+		panic(ErrNilObject)
+	}
+	n := prefix.ReadableBytes()
+	if n > bb.ReadableBytes() {
+		return false
+	}
+	return compareRange(bb, bb.ReaderIndex(), prefix, prefix.ReaderIndex(), n) == 0
+}
+
+// HasSuffix reports whether the readable region of bb ends with the
+// readable region of suffix.
+func HasSuffix(bb, suffix ByteBuf) bool {
+	if bb == nil || suffix == nil {
+		panic(ErrNilObject)
+	}
+	n := suffix.ReadableBytes()
+	if n > bb.ReadableBytes() {
+		return false
+	}
+	return compareRange(bb, bb.WriterIndex()-n, suffix, suffix.ReaderIndex(), n) == 0
+}
+
+// compareRange compares n bytes of a starting at aFrom with n bytes of b
+// starting at bFrom, walking the segments of both sides in lockstep.
+func compareRange(a ByteBuf, aFrom int, b ByteBuf, bFrom int, n int) int {
+	ca, cb := newSegmentCursor(a, aFrom, n), newSegmentCursor(b, bFrom, n)
+	var sa, sb []byte
+	for {
+		if len(sa) == 0 {
+			_, sa = ca.next()
+		}
+		if len(sb) == 0 {
+			_, sb = cb.next()
+		}
+		if len(sa) == 0 || len(sb) == 0 {
+			return 0
+		}
+		k := min(len(sa), len(sb))
+		if c := bytes.Compare(sa[:k], sb[:k]); c != 0 {
+			return c
+		}
+		sa, sb = sa[k:], sb[k:]
+	}
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// Hash returns a 64-bit hash of the readable region of bb. The result
+// depends only on the bytes and seed, so it is stable across component
+// layouts, processes and releases and can key caches and dedup tables. It
+// is seeded FNV-1a with a final avalanche and is not cryptographic.
+func Hash(bb ByteBuf, seed uint64) uint64 {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	h := uint64(fnvOffset64) ^ seed
+	cur := newSegmentCursor(bb, bb.ReaderIndex(), bb.ReadableBytes())
+	for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+		for _, c := range seg {
+			h ^= uint64(c)
+			h *= fnvPrime64
+		}
+	}
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
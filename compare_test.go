@@ -0,0 +1,63 @@
+package buf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Buffers with different component layouts compare by content only.
+func TestEqualCompare_Layouts(t *testing.T) {
+	flat := bb("hello, world")
+	split := NewCompositeByteBuf(bb("hel"), bb("lo, w"), bb("orld"))
+	other := NewCompositeByteBuf(bb("hello"), bb(", world"))
+	consumed := bb("xxhello, world")
+	consumed.Skip(2)
+
+	for _, b := range []ByteBuf{split, other, consumed} {
+		assert.True(t, Equal(flat, b))
+		assert.True(t, Equal(b, flat))
+		assert.Equal(t, 0, Compare(flat, b))
+		assert.Equal(t, Hash(flat, 7), Hash(b, 7))
+	}
+	assert.Equal(t, 3, len(asDefault(split).components))
+	assert.Equal(t, 0, split.ReaderIndex())
+
+	assert.False(t, Equal(flat, bb("hello, worle")))
+	assert.False(t, Equal(flat, bb("hello")))
+	assert.Equal(t, -1, Compare(split, bb("hello, worle")))
+	assert.Equal(t, 1, Compare(split, NewCompositeByteBuf(bb("hello, "), bb("apple"))))
+	assert.Equal(t, 1, Compare(split, bb("hello")))
+	assert.Equal(t, -1, Compare(bb(""), split))
+	assert.True(t, Equal(EmptyByteBuf(), NewCompositeByteBuf()))
+}
+
+func TestHasPrefixSuffix(t *testing.T) {
+	c := NewCompositeByteBuf(bb("GET /"), bb("index"), bb(" HTTP/1.1\r\n"))
+	c.Skip(4)
+	assert.True(t, HasPrefix(c, bb("/ind")))
+	assert.False(t, HasPrefix(c, bb("GET")))
+	assert.True(t, HasSuffix(c, NewCompositeByteBuf(bb("1.1"), bb("\r\n"))))
+	assert.True(t, HasSuffix(c, EmptyByteBuf()))
+	assert.False(t, HasSuffix(bb("ab"), bb("xab")))
+	assert.Equal(t, 4, c.ReaderIndex())
+}
+
+// The hash is a fixed function of bytes and seed.
+func TestHash_Stable(t *testing.T) {
+	assert.Equal(t, uint64(0x5751a8d653d5e415), Hash(bb("bytebuf"), 0))
+	assert.NotEqual(t, Hash(bb("bytebuf"), 0), Hash(bb("bytebuf"), 1))
+	assert.NotEqual(t, Hash(bb("ab"), 0), Hash(bb("ba"), 0))
+}
+
+func TestEqual_NoAllocs(t *testing.T) {
+	a := NewCompositeByteBuf(bb("abc"), bb("defgh"), bb("ij"))
+	b := NewCompositeByteBuf(bb("abcde"), bb("fghij"))
+	allocs := testing.AllocsPerRun(100, func() {
+		Equal(a, b)
+		Compare(a, b)
+		HasSuffix(a, b)
+		Hash(a, 1)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -1,6 +1,7 @@
 package buf
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
 	"io"
@@ -19,6 +20,11 @@ var ErrCompositeOutOfRange = errors.New("composite byte buf: out of range")
 // Components alias their sources, so mutations through the source (within
 // the original capacity) remain visible through the composite.
 //
+// The composites made by this package also implement the optional
+// RetainedSlicer, TryReader, VarintCodec, AbsoluteAccessor, io.ReaderFrom,
+// io.ReaderAt, io.Seeker, io.ByteScanner and io.RuneScanner interfaces;
+// detect them with a type assertion.
+//
 // CompositeByteBuf is NOT goroutine-safe.
 type CompositeByteBuf interface {
 	ByteBuf
@@ -42,23 +48,33 @@ type compositeComponent struct {
 }
 
 type defaultCompositeByteBuf struct {
-	components      []compositeComponent
-	tail            *DefaultByteBuf // when non-nil, last component aliases tail.Bytes()
-	readerIdx       int
-	writerIdx       int
-	prevReaderIdx   int
-	prevWriterIdx   int
-	lastHit         int
-	refcnt          atomic.Int32
+	components    []compositeComponent
+	tail          *DefaultByteBuf // when non-nil, last component aliases tail.Bytes()
+	readerIdx     int
+	writerIdx     int
+	prevReaderIdx int
+	prevWriterIdx int
+	lastHit       int
+	lastRune      int                      // readerIdx before the last ReadRune plus one; 0 disallows UnreadRune
+	discarded     int                      // bytes dropped from the front by Compact
+	txPin         int                      // see bufSide.txPin
+	alloc         Allocator                // source of tails; nil allocates directly
+	owned         []ByteBuf                // tails drawn from alloc, handed back by Reset, Close and releaseOwned
+	releaseTo     Allocator                // set when releaseOwned waits for the final Release
+	root          *defaultCompositeByteBuf // see bufSide.root
+	refcnt        atomic.Int32
 }
 
 // Compile-time assertions guarding the ByteBuf / Slicer / RefCounted /
-// CompositeByteBuf / io.WriterTo contracts.
+// TryReader / CompositeByteBuf / io.ReaderFrom / io.WriterTo contracts.
 var (
 	_ ByteBuf          = (*defaultCompositeByteBuf)(nil)
 	_ Slicer           = (*defaultCompositeByteBuf)(nil)
+	_ RetainedSlicer   = (*defaultCompositeByteBuf)(nil)
 	_ RefCounted       = (*defaultCompositeByteBuf)(nil)
+	_ TryReader        = (*defaultCompositeByteBuf)(nil)
 	_ CompositeByteBuf = (*defaultCompositeByteBuf)(nil)
+	_ io.ReaderFrom    = (*defaultCompositeByteBuf)(nil)
 	_ io.WriterTo      = (*defaultCompositeByteBuf)(nil)
 )
 
@@ -70,6 +86,56 @@ func NewCompositeByteBuf(bbs ...ByteBuf) CompositeByteBuf {
 	return c.AddComponents(bbs...)
 }
 
+// newAllocatedComposite returns an empty composite drawing its tails from
+// a.
+func newAllocatedComposite(a Allocator) *defaultCompositeByteBuf {
+	c := &defaultCompositeByteBuf{alloc: a}
+	c.refcnt.Store(1)
+	return c
+}
+
+// dropOwned hands the tails drawn from the allocator back to it once no
+// component refers to them any more.
+func (c *defaultCompositeByteBuf) dropOwned() {
+	for _, t := range c.owned {
+		c.alloc.Release(t)
+	}
+	clear(c.owned)
+	c.owned = c.owned[:0]
+}
+
+// allocator returns the allocator c's tails are drawn from, which for a
+// retained view is its root's.
+func (c *defaultCompositeByteBuf) allocator() Allocator {
+	if c.root != nil {
+		return c.root.alloc
+	}
+	return c.alloc
+}
+
+// releaseOwned hands every tail drawn from the allocator back to a,
+// closes the composite and drops its refcount to 0. While retained views
+// still hold references it only drops one, and the final Release does the
+// rest.
+func (c *defaultCompositeByteBuf) releaseOwned(a Allocator) {
+	if c.root != nil {
+		c.root.releaseOwned(a)
+		return
+	}
+	if c.refcnt.Load() > 1 {
+		c.releaseTo = a
+		c.Release()
+		return
+	}
+	owned := c.owned
+	c.owned = nil
+	c.Close()
+	c.refcnt.Store(0)
+	for _, t := range owned {
+		a.Release(t)
+	}
+}
+
 // AddComponent appends bb's readable region as a new component. When bb is
 // itself a CompositeByteBuf, the underlying components are flattened in so
 // nested composites do not accumulate.
@@ -214,29 +280,35 @@ func (c *defaultCompositeByteBuf) ResetWriterIndex() ByteBuf {
 	return c
 }
 
-// Reset clears all indices (reader, writer, and marks) while keeping the
-// components. Use Close to drop components and backing storage.
+// Reset clears all indices (reader, writer, and marks) and drops the
+// components. Tails drawn from the composite's allocator go back to it, so
+// no view of the composite may be used afterwards.
 func (c *defaultCompositeByteBuf) Reset() ByteBuf {
 	c.readerIdx = 0
 	c.writerIdx = 0
 	c.prevReaderIdx = 0
 	c.prevWriterIdx = 0
 	c.lastHit = 0
+	c.lastRune = 0
 	c.components = nil
 	c.tail = nil
+	c.dropOwned()
 	return c
 }
 
 // Close drops every component and the writable tail, and zeroes all
-// indices. RefCnt is unaffected; refcount management is via Retain/Release.
+// indices. Tails drawn from the composite's allocator go back to it, as on
+// Reset. RefCnt is unaffected; refcount management is via Retain/Release.
 func (c *defaultCompositeByteBuf) Close() error {
 	c.components = nil
 	c.tail = nil
+	c.dropOwned()
 	c.readerIdx = 0
 	c.writerIdx = 0
 	c.prevReaderIdx = 0
 	c.prevWriterIdx = 0
 	c.lastHit = 0
+	c.lastRune = 0
 	return nil
 }
 
@@ -346,12 +418,14 @@ func (c *defaultCompositeByteBuf) decomposeReadable() [][]byte {
 // Compact drops components whose bytes are fully consumed and trims the
 // front of the first partially-consumed component. The readable region is
 // preserved; readerIdx resets to 0 and marked indices shift accordingly.
+// Inside a Transaction the bytes it may roll back to are kept.
 func (c *defaultCompositeByteBuf) Compact() ByteBuf {
-	if c.readerIdx == 0 {
+	shift := c.txFloor(c.readerIdx)
+	if shift == 0 {
 		return c
 	}
-	shift := c.readerIdx
-	if c.readerIdx >= c.writerIdx {
+	c.discarded += shift
+	if shift >= c.writerIdx {
 		// Everything consumed: drop all components.
 		c.components = nil
 		c.tail = nil
@@ -360,9 +434,10 @@ func (c *defaultCompositeByteBuf) Compact() ByteBuf {
 		c.prevReaderIdx = 0
 		c.prevWriterIdx = 0
 		c.lastHit = 0
+		c.lastRune = 0
 		return c
 	}
-	compIdx, offsetIn := c.locate(c.readerIdx)
+	compIdx, offsetIn := c.locate(shift)
 	c.components = append(c.components[:0], c.components[compIdx:]...)
 	if offsetIn > 0 {
 		c.components[0].data = c.components[0].data[offsetIn:]
@@ -371,7 +446,7 @@ func (c *defaultCompositeByteBuf) Compact() ByteBuf {
 		c.components[i].endOffset -= shift
 	}
 	c.writerIdx -= shift
-	c.readerIdx = 0
+	c.readerIdx -= shift
 	if c.prevReaderIdx > 0 {
 		if c.prevReaderIdx >= shift {
 			c.prevReaderIdx -= shift
@@ -387,6 +462,7 @@ func (c *defaultCompositeByteBuf) Compact() ByteBuf {
 		}
 	}
 	c.lastHit = 0
+	c.lastRune = 0
 	return c
 }
 
@@ -446,7 +522,19 @@ func (c *defaultCompositeByteBuf) ensureTail() {
 	if c.tail != nil {
 		return
 	}
-	c.tail = newDefaultByteBuf()
+	c.tail = nil
+	if c.alloc != nil {
+		bb := c.alloc.Buffer(0)
+		if t, ok := bb.(*DefaultByteBuf); ok {
+			c.tail = t
+			c.owned = append(c.owned, bb)
+		} else {
+			c.alloc.Release(bb)
+		}
+	}
+	if c.tail == nil {
+		c.tail = newDefaultByteBuf()
+	}
 	c.components = append(c.components, compositeComponent{
 		data:      nil,
 		endOffset: c.writerIdx,
@@ -587,9 +675,19 @@ func (c *defaultCompositeByteBuf) WriteReader(reader io.Reader) ByteBuf {
 	return c
 }
 
-func (c *defaultCompositeByteBuf) WriteInt16(v int16) ByteBuf  { c.WriteUInt16(uint16(v)); return c }
-func (c *defaultCompositeByteBuf) WriteInt32(v int32) ByteBuf  { c.WriteUInt32(uint32(v)); return c }
-func (c *defaultCompositeByteBuf) WriteInt64(v int64) ByteBuf  { c.WriteUInt64(uint64(v)); return c }
+// ReadFrom implements io.ReaderFrom by reading into the writable tail. Read
+// errors other than io.EOF are returned; bytes read before them are kept.
+func (c *defaultCompositeByteBuf) ReadFrom(r io.Reader) (n int64, err error) {
+	if r == nil {
+		panic(ErrNilObject)
+	}
+	c.tailWrite(func(t *DefaultByteBuf) { n, err = t.ReadFrom(r) })
+	return n, err
+}
+
+func (c *defaultCompositeByteBuf) WriteInt16(v int16) ByteBuf   { c.WriteUInt16(uint16(v)); return c }
+func (c *defaultCompositeByteBuf) WriteInt32(v int32) ByteBuf   { c.WriteUInt32(uint32(v)); return c }
+func (c *defaultCompositeByteBuf) WriteInt64(v int64) ByteBuf   { c.WriteUInt64(uint64(v)); return c }
 func (c *defaultCompositeByteBuf) WriteInt16LE(v int16) ByteBuf { c.WriteUInt16LE(uint16(v)); return c }
 func (c *defaultCompositeByteBuf) WriteInt32LE(v int32) ByteBuf { c.WriteUInt32LE(uint32(v)); return c }
 func (c *defaultCompositeByteBuf) WriteInt64LE(v int64) ByteBuf { c.WriteUInt64LE(uint64(v)); return c }
@@ -755,6 +853,66 @@ func (c *defaultCompositeByteBuf) readMultiByte(dst []byte, n int) {
 	c.readerIdx += n
 }
 
+// copyAt copies up to len(dst) bytes starting at absolute position pos into
+// dst without touching any index, and returns the number of bytes copied.
+// Copying stops at writerIdx.
+func (c *defaultCompositeByteBuf) copyAt(pos int, dst []byte) int {
+	n := min(len(dst), c.writerIdx-pos)
+	if n <= 0 {
+		return 0
+	}
+	compIdx, offIn := c.locate(pos)
+	copied := copy(dst[:n], c.components[compIdx].data[offIn:])
+	for i := compIdx + 1; copied < n; i++ {
+		copied += copy(dst[copied:n], c.components[i].data)
+	}
+	return n
+}
+
+// hasBytesAt reports whether the bytes at absolute position pos equal sep,
+// comparing segment by segment across component boundaries.
+func (c *defaultCompositeByteBuf) hasBytesAt(pos int, sep []byte) bool {
+	if pos < 0 || pos+len(sep) > c.writerIdx {
+		return false
+	}
+	matched := 0
+	for matched < len(sep) {
+		compIdx, offIn := c.locate(pos + matched)
+		seg := c.components[compIdx].data[offIn:]
+		n := min(len(seg), len(sep)-matched)
+		if !bytes.Equal(seg[:n], sep[matched:matched+n]) {
+			return false
+		}
+		matched += n
+	}
+	return true
+}
+
+// indexOf returns the offset, relative to readerIdx, of the first
+// occurrence of sep at or after from within the readable region, or -1.
+// Components are scanned in place; nothing is consolidated.
+func (c *defaultCompositeByteBuf) indexOf(from int, sep []byte) int {
+	if len(sep) == 0 {
+		return from
+	}
+	for pos := c.readerIdx + from; pos+len(sep) <= c.writerIdx; {
+		compIdx, offIn := c.locate(pos)
+		seg := c.components[compIdx].data[offIn:]
+		seg = seg[:min(len(seg), c.writerIdx-pos)]
+		i := bytes.IndexByte(seg, sep[0])
+		if i < 0 {
+			pos += len(seg)
+			continue
+		}
+		pos += i
+		if c.hasBytesAt(pos, sep) {
+			return pos - c.readerIdx
+		}
+		pos++
+	}
+	return -1
+}
+
 func (c *defaultCompositeByteBuf) ReadUInt16() uint16 {
 	var tmp [2]byte
 	c.readMultiByte(tmp[:], 2)
@@ -812,6 +970,139 @@ func (c *defaultCompositeByteBuf) ReadFloat64LE() float64 {
 	return math.Float64frombits(c.ReadUInt64LE())
 }
 
+// ---------- TryReader ----------
+
+// TrySkip advances readerIdx by n bytes. It returns ErrInsufficientSize
+// without moving the index when n is negative or exceeds ReadableBytes.
+func (c *defaultCompositeByteBuf) TrySkip(n int) error {
+	if n < 0 || c.ReadableBytes() < n {
+		return ErrInsufficientSize
+	}
+	c.readerIdx += n
+	return nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadBytes(n int) ([]byte, error) {
+	if n < 0 || c.ReadableBytes() < n {
+		return nil, ErrInsufficientSize
+	}
+	return c.ReadBytes(n), nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadByteBuf(n int) (ByteBuf, error) {
+	if n < 0 || c.ReadableBytes() < n {
+		return nil, ErrInsufficientSize
+	}
+	return c.ReadByteBuf(n), nil
+}
+
+// tryReadMultiByte is the non-panicking form of readMultiByte.
+func (c *defaultCompositeByteBuf) tryReadMultiByte(dst []byte, n int) error {
+	if c.ReadableBytes() < n {
+		return ErrInsufficientSize
+	}
+	c.readMultiByte(dst, n)
+	return nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadUInt16() (uint16, error) {
+	var tmp [2]byte
+	if err := c.tryReadMultiByte(tmp[:], 2); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(tmp[:]), nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadUInt32() (uint32, error) {
+	var tmp [4]byte
+	if err := c.tryReadMultiByte(tmp[:], 4); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(tmp[:]), nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadUInt64() (uint64, error) {
+	var tmp [8]byte
+	if err := c.tryReadMultiByte(tmp[:], 8); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(tmp[:]), nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadInt16() (int16, error) {
+	v, err := c.TryReadUInt16()
+	return int16(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadInt32() (int32, error) {
+	v, err := c.TryReadUInt32()
+	return int32(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadInt64() (int64, error) {
+	v, err := c.TryReadUInt64()
+	return int64(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadFloat32() (float32, error) {
+	v, err := c.TryReadUInt32()
+	return math.Float32frombits(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadFloat64() (float64, error) {
+	v, err := c.TryReadUInt64()
+	return math.Float64frombits(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadUInt16LE() (uint16, error) {
+	var tmp [2]byte
+	if err := c.tryReadMultiByte(tmp[:], 2); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(tmp[:]), nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadUInt32LE() (uint32, error) {
+	var tmp [4]byte
+	if err := c.tryReadMultiByte(tmp[:], 4); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(tmp[:]), nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadUInt64LE() (uint64, error) {
+	var tmp [8]byte
+	if err := c.tryReadMultiByte(tmp[:], 8); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(tmp[:]), nil
+}
+
+func (c *defaultCompositeByteBuf) TryReadInt16LE() (int16, error) {
+	v, err := c.TryReadUInt16LE()
+	return int16(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadInt32LE() (int32, error) {
+	v, err := c.TryReadUInt32LE()
+	return int32(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadInt64LE() (int64, error) {
+	v, err := c.TryReadUInt64LE()
+	return int64(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadFloat32LE() (float32, error) {
+	v, err := c.TryReadUInt32LE()
+	return math.Float32frombits(v), err
+}
+
+func (c *defaultCompositeByteBuf) TryReadFloat64LE() (float64, error) {
+	v, err := c.TryReadUInt64LE()
+	return math.Float64frombits(v), err
+}
+
 // ---------- Slicer ----------
 
 // Slice returns a sub-composite view over [from, from+length) within the
@@ -877,22 +1168,67 @@ func (c *defaultCompositeByteBuf) ReadSlice(n int) ByteBuf {
 	return view
 }
 
+// RetainedSlice is Slice returning a view that shares c's reference count.
+func (c *defaultCompositeByteBuf) RetainedSlice(from, length int) ByteBuf {
+	return c.retainView(c.Slice(from, length).(*defaultCompositeByteBuf))
+}
+
+// RetainedDuplicate is Duplicate returning a view that shares c's
+// reference count.
+func (c *defaultCompositeByteBuf) RetainedDuplicate() ByteBuf {
+	return c.retainView(c.Duplicate().(*defaultCompositeByteBuf))
+}
+
+// ReadRetainedSlice is ReadSlice returning a view that shares c's
+// reference count.
+func (c *defaultCompositeByteBuf) ReadRetainedSlice(n int) ByteBuf {
+	return c.retainView(c.ReadSlice(n).(*defaultCompositeByteBuf))
+}
+
+func (c *defaultCompositeByteBuf) retainView(v *defaultCompositeByteBuf) ByteBuf {
+	root := c
+	if c.root != nil {
+		root = c.root
+	}
+	root.Retain()
+	v.root = root
+	return v
+}
+
 // ---------- RefCounted ----------
 
 func (c *defaultCompositeByteBuf) Retain() ByteBuf {
+	if c.root != nil {
+		c.root.Retain()
+		return c
+	}
 	c.refcnt.Add(1)
 	return c
 }
 
+// Release drops a reference; a retained view drops its root's. When an
+// allocator release was waiting for retained views, the final Release
+// completes it.
 func (c *defaultCompositeByteBuf) Release() bool {
+	if c.root != nil {
+		return c.root.Release()
+	}
 	n := c.refcnt.Add(-1)
 	if n < 0 {
 		panic(ErrRefCountUnderflow)
 	}
+	if n == 0 && c.releaseTo != nil {
+		a := c.releaseTo
+		c.releaseTo = nil
+		c.releaseOwned(a)
+	}
 	return n == 0
 }
 
 func (c *defaultCompositeByteBuf) RefCnt() int32 {
+	if c.root != nil {
+		return c.root.refcnt.Load()
+	}
 	return c.refcnt.Load()
 }
 
@@ -919,6 +1255,9 @@ func (c *defaultCompositeByteBuf) WriteTo(w io.Writer) (int64, error) {
 	for _, seg := range segs {
 		n, err := w.Write(seg)
 		total += int64(n)
+		if err == nil && n < len(seg) {
+			err = io.ErrShortWrite
+		}
 		if err != nil {
 			c.readerIdx += int(total)
 			return total, err
@@ -533,6 +533,34 @@ func TestComposite_Refcount(t *testing.T) {
 	assert.Panics(t, func() { c.Release() })
 }
 
+// Retained views count against the root, and an allocator release of the
+// root waits until they are released.
+func TestComposite_RetainedViews(t *testing.T) {
+	ta := NewTrackingAllocator(Pooled)
+	c := ta.Composite()
+	c.WriteString("hello")
+	c.AddComponent(bb(" world"))
+
+	rs := c.(RetainedSlicer)
+	head := rs.ReadRetainedSlice(5)
+	dup := rs.RetainedDuplicate()
+	word := dup.(RetainedSlicer).RetainedSlice(1, 5)
+	assert.Equal(t, int32(4), c.RefCnt())
+	assert.Equal(t, int32(4), word.(RefCounted).RefCnt())
+	assert.Equal(t, "world", string(word.BytesCopy()))
+
+	ta.Release(c)
+	assert.Equal(t, int32(3), head.(RefCounted).RefCnt())
+	assert.Equal(t, 1, ta.Outstanding(), "tail kept while views are live")
+	assert.Equal(t, "hello", string(head.BytesCopy()))
+
+	assert.False(t, dup.(RefCounted).Release())
+	assert.False(t, word.(RefCounted).Release())
+	assert.True(t, head.(RefCounted).Release())
+	assert.Equal(t, 0, ta.Outstanding())
+	assert.Equal(t, 0, c.ReadableBytes())
+}
+
 // --- Pool does not accept composite --------------------------------------
 
 func TestComposite_NotPooled(t *testing.T) {
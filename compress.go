@@ -0,0 +1,485 @@
+package buf
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrUnknownCodec is returned when a CompressionCodec value is not one of
+// the defined codecs.
+var ErrUnknownCodec = errors.New("unknown compression codec")
+
+// ErrDecompressLimit is returned when decompressed output would exceed the
+// configured maximum. The output written so far stays in dst.
+var ErrDecompressLimit = errors.New("decompressed output exceeds limit")
+
+// CompressionCodec selects the container format of a compressed stream.
+type CompressionCodec int
+
+const (
+	// CodecGzip is the gzip format of RFC 1952.
+	CodecGzip CompressionCodec = iota
+	// CodecZlib is the zlib format of RFC 1950.
+	CodecZlib
+	// CodecDeflate is raw deflate data as in RFC 1951, the format used by
+	// the WebSocket permessage-deflate extension.
+	CodecDeflate
+)
+
+// deflateWindow is the size of the deflate sliding window.
+const deflateWindow = 32 << 10
+
+// decompressChunk bounds the writable space reserved per read so a single
+// read never grows dst far past the limit.
+const decompressChunk = 16 << 10
+
+// syncFlushMarker is the LEN/NLEN of the empty stored block a sync flush
+// ends with. RFC 7692 strips it from each message and has the receiver
+// append it again.
+var syncFlushMarker = [4]byte{0x00, 0x00, 0xff, 0xff}
+
+// syncFlushTrailer is appended to deflate input: the stripped marker,
+// followed by an empty final stored block so the message ends with io.EOF
+// instead of io.ErrUnexpectedEOF.
+var syncFlushTrailer = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+// CompressTo compresses the readable region of src into dst as one
+// complete stream at the default level. src is marked as consumed.
+func CompressTo(dst, src ByteBuf, codec CompressionCodec) error {
+	if dst == nil || src == nil {
+		panic(ErrNilObject)
+	}
+	if codec < CodecGzip || codec > CodecDeflate {
+		return ErrUnknownCodec
+	}
+	c := compressorPools[codec].Get().(*Compressor)
+	defer compressorPools[codec].Put(c)
+	c.Reset()
+	c.sink.bb = dst
+	if err := c.write(src); err != nil {
+		c.Reset()
+		return err
+	}
+	return c.Finish(dst)
+}
+
+// DecompressTo decompresses one complete stream from src into dst. src is
+// advanced past the bytes the decoder consumed. When maxOutput is positive
+// no more than maxOutput bytes are written and ErrDecompressLimit is
+// returned for larger streams.
+func DecompressTo(dst, src ByteBuf, codec CompressionCodec, maxOutput int) error {
+	if dst == nil || src == nil {
+		panic(ErrNilObject)
+	}
+	if codec < CodecGzip || codec > CodecDeflate {
+		return ErrUnknownCodec
+	}
+	d := decompressorPools[codec].Get().(*Decompressor)
+	defer decompressorPools[codec].Put(d)
+	d.Reset()
+	d.maxOutput = maxOutput
+	return d.DecompressTo(dst, src)
+}
+
+var (
+	compressorPools   [CodecDeflate + 1]sync.Pool
+	decompressorPools [CodecDeflate + 1]sync.Pool
+)
+
+func init() {
+	for i := range compressorPools {
+		codec := CompressionCodec(i)
+		compressorPools[i].New = func() any {
+			c, _ := NewCompressor(codec, flate.DefaultCompression)
+			return c
+		}
+		decompressorPools[i].New = func() any {
+			d, _ := NewDecompressor(codec, 0)
+			return d
+		}
+	}
+}
+
+// ---------- Compressor ----------
+
+// compressWriter is the common surface of the flate, gzip and zlib writers.
+type compressWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// byteBufSink forwards writes to a ByteBuf that can be swapped per call.
+// While hold is set the last four bytes written are kept back in tail, so
+// a trailing sync flush marker can be dropped.
+type byteBufSink struct {
+	bb   ByteBuf
+	hold bool
+	tail [4]byte
+	n    int
+}
+
+func (s *byteBufSink) Write(p []byte) (int, error) {
+	if !s.hold {
+		s.bb.WriteBytes(p)
+		return len(p), nil
+	}
+	over := s.n + len(p) - len(s.tail)
+	if over <= 0 {
+		s.n += copy(s.tail[s.n:], p)
+		return len(p), nil
+	}
+	e := min(s.n, over)
+	s.bb.WriteBytes(s.tail[:e])
+	s.n = copy(s.tail[:], s.tail[e:s.n])
+	s.bb.WriteBytes(p[:over-e])
+	s.n += copy(s.tail[s.n:], p[over-e:])
+	return len(p), nil
+}
+
+// release ends holding. It drops the held bytes when they are the sync
+// flush marker and writes them out otherwise.
+func (s *byteBufSink) release() {
+	s.hold = false
+	held := s.tail[:s.n]
+	s.n = 0
+	if len(held) != len(s.tail) || s.tail != syncFlushMarker {
+		s.bb.WriteBytes(held)
+	}
+}
+
+// Compressor is a streaming compressor. Each CompressTo call appends the
+// compressed form of its input followed by a sync flush, so the output so
+// far is decodable on its own while the stream, and for deflate its
+// window, carries over to the next call. Finish ends the stream.
+//
+// For deflate the trailing 00 00 ff ff of each sync flush is stripped, as
+// RFC 7692 does for permessage-deflate, so every CompressTo output is a
+// message for Decompressor, which appends those bytes again, rather than
+// a piece of one deflate stream.
+//
+// Compressor is NOT goroutine-safe.
+type Compressor struct {
+	codec CompressionCodec
+	alloc Allocator
+	sink  byteBufSink
+	w     compressWriter
+}
+
+// NewCompressor returns a compressor for codec at the given flate level.
+func NewCompressor(codec CompressionCodec, level int) (*Compressor, error) {
+	c := &Compressor{codec: codec, alloc: Pooled}
+	var err error
+	switch codec {
+	case CodecGzip:
+		c.w, err = gzip.NewWriterLevel(&c.sink, level)
+	case CodecZlib:
+		c.w, err = zlib.NewWriterLevel(&c.sink, level)
+	case CodecDeflate:
+		c.w, err = flate.NewWriter(&c.sink, level)
+	default:
+		return nil, ErrUnknownCodec
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Codec returns the codec c was created for.
+func (c *Compressor) Codec() CompressionCodec { return c.codec }
+
+// SetAllocator selects the allocator Compress draws its output from. The
+// default is Pooled.
+func (c *Compressor) SetAllocator(a Allocator) {
+	if a == nil {
+		panic(ErrNilObject)
+	}
+	c.alloc = a
+}
+
+// CompressTo compresses the readable region of src into dst and marks src
+// as consumed. Composite sources are fed segment by segment.
+func (c *Compressor) CompressTo(dst, src ByteBuf) error {
+	if dst == nil || src == nil {
+		panic(ErrNilObject)
+	}
+	c.sink.bb = dst
+	defer func() { c.sink.bb = nil }()
+	c.sink.hold = c.codec == CodecDeflate
+	err := c.write(src)
+	if err == nil {
+		err = c.w.Flush()
+	}
+	if c.sink.hold {
+		c.sink.release()
+	}
+	return err
+}
+
+// write feeds the readable region of src to the writer and marks src as
+// consumed.
+func (c *Compressor) write(src ByteBuf) error {
+	n := src.ReadableBytes()
+	cur := newSegmentCursor(src, src.ReaderIndex(), n)
+	for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+		if _, err := c.w.Write(seg); err != nil {
+			return err
+		}
+	}
+	src.Skip(n)
+	return nil
+}
+
+// Compress is CompressTo into a buffer from the allocator. The caller
+// hands the result back to the same allocator; with the default Pooled
+// allocator ReleaseByteBuf does the same.
+func (c *Compressor) Compress(src ByteBuf) (ByteBuf, error) {
+	if src == nil {
+		panic(ErrNilObject)
+	}
+	out := c.alloc.Buffer(src.ReadableBytes()/2 + 64)
+	if err := c.CompressTo(out, src); err != nil {
+		c.alloc.Release(out)
+		return nil, err
+	}
+	return out, nil
+}
+
+// Finish writes the end of the stream to dst and resets c so the next
+// CompressTo starts a new stream.
+func (c *Compressor) Finish(dst ByteBuf) error {
+	if dst == nil {
+		panic(ErrNilObject)
+	}
+	c.sink.bb = dst
+	err := c.w.Close()
+	c.sink.bb = nil
+	c.w.Reset(&c.sink)
+	return err
+}
+
+// Reset discards the current stream without writing its end.
+func (c *Compressor) Reset() {
+	c.sink.bb = nil
+	c.w.Reset(&c.sink)
+}
+
+// ---------- Decompressor ----------
+
+// byteBufSource reads a ByteBuf followed by an optional trailer. It
+// implements io.ByteReader so the decoders consume no more input than they
+// need.
+type byteBufSource struct {
+	bb      ByteBuf
+	trailer []byte
+}
+
+func (s *byteBufSource) Read(p []byte) (int, error) {
+	if s.bb.ReadableBytes() > 0 {
+		return s.bb.Read(p)
+	}
+	if len(s.trailer) > 0 {
+		n := copy(p, s.trailer)
+		s.trailer = s.trailer[n:]
+		return n, nil
+	}
+	return 0, io.EOF
+}
+
+func (s *byteBufSource) ReadByte() (byte, error) {
+	if s.bb.ReadableBytes() > 0 {
+		return s.bb.MustReadByte(), nil
+	}
+	if len(s.trailer) > 0 {
+		b := s.trailer[0]
+		s.trailer = s.trailer[1:]
+		return b, nil
+	}
+	return 0, io.EOF
+}
+
+// Decompressor is a streaming decompressor. Each DecompressTo call consumes
+// one unit from src: a single member for gzip, a complete stream for zlib,
+// or for deflate a message that ends either with a final block or
+// with a sync flush stripped of its 00 00 ff ff, as produced by
+// Compressor.CompressTo and RFC 7692 peers. Deflate messages share a sliding
+// window, matching a compressor that keeps its context between messages;
+// call Reset between messages when the peer does not.
+//
+// Decompressor is NOT goroutine-safe.
+type Decompressor struct {
+	codec     CompressionCodec
+	alloc     Allocator
+	maxOutput int
+	src       byteBufSource
+	r         io.ReadCloser
+	window    []byte
+}
+
+// NewDecompressor returns a decompressor for codec. When maxOutput is
+// positive each DecompressTo call writes at most maxOutput bytes.
+func NewDecompressor(codec CompressionCodec, maxOutput int) (*Decompressor, error) {
+	if codec < CodecGzip || codec > CodecDeflate {
+		return nil, ErrUnknownCodec
+	}
+	return &Decompressor{codec: codec, alloc: Pooled, maxOutput: maxOutput}, nil
+}
+
+// Codec returns the codec d was created for.
+func (d *Decompressor) Codec() CompressionCodec { return d.codec }
+
+// SetAllocator selects the allocator Decompress draws its output from. The
+// default is Pooled.
+func (d *Decompressor) SetAllocator(a Allocator) {
+	if a == nil {
+		panic(ErrNilObject)
+	}
+	d.alloc = a
+}
+
+// DecompressTo decompresses the next unit of src into dst and advances src
+// past the consumed input. It returns ErrDecompressLimit once the output of
+// this call would exceed the limit; d must then be Reset.
+func (d *Decompressor) DecompressTo(dst, src ByteBuf) error {
+	if dst == nil || src == nil {
+		panic(ErrNilObject)
+	}
+	d.src = byteBufSource{bb: src}
+	defer func() { d.src.bb = nil }()
+	if err := d.open(); err != nil {
+		return err
+	}
+	n, err := copyLimited(dst, d.r, d.maxOutput)
+	if err != nil {
+		return err
+	}
+	if d.codec == CodecDeflate {
+		d.slideWindow(dst, n)
+	}
+	return nil
+}
+
+// Decompress is DecompressTo into a buffer from the allocator. The caller
+// hands the result back to the same allocator; with the default Pooled
+// allocator ReleaseByteBuf does the same.
+func (d *Decompressor) Decompress(src ByteBuf) (ByteBuf, error) {
+	if src == nil {
+		panic(ErrNilObject)
+	}
+	out := d.alloc.Buffer(src.ReadableBytes() * 2)
+	if err := d.DecompressTo(out, src); err != nil {
+		d.alloc.Release(out)
+		return nil, err
+	}
+	return out, nil
+}
+
+// Reset drops the deflate window so the next message decodes without
+// history.
+func (d *Decompressor) Reset() {
+	d.window = d.window[:0]
+}
+
+// open points the decoder at d.src, creating it on first use.
+func (d *Decompressor) open() error {
+	switch d.codec {
+	case CodecGzip:
+		if d.r == nil {
+			r, err := gzip.NewReader(&d.src)
+			if err != nil {
+				return err
+			}
+			r.Multistream(false)
+			d.r = r
+			return nil
+		}
+		r := d.r.(*gzip.Reader)
+		if err := r.Reset(&d.src); err != nil {
+			return err
+		}
+		r.Multistream(false)
+		return nil
+	case CodecZlib:
+		if d.r == nil {
+			r, err := zlib.NewReader(&d.src)
+			if err != nil {
+				return err
+			}
+			d.r = r
+			return nil
+		}
+		return d.r.(zlib.Resetter).Reset(&d.src, nil)
+	default:
+		d.src.trailer = syncFlushTrailer
+		if d.r == nil {
+			d.r = flate.NewReader(&d.src)
+		}
+		return d.r.(flate.Resetter).Reset(&d.src, d.window)
+	}
+}
+
+// slideWindow appends the last n bytes written to dst to the deflate
+// window, keeping at most deflateWindow bytes.
+func (d *Decompressor) slideWindow(dst ByteBuf, n int) {
+	if n > deflateWindow {
+		n = deflateWindow
+	}
+	cur := newSegmentCursor(dst, dst.WriterIndex()-n, n)
+	for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+		d.window = append(d.window, seg...)
+	}
+	if over := len(d.window) - deflateWindow; over > 0 {
+		d.window = d.window[:copy(d.window, d.window[over:])]
+	}
+}
+
+// copyLimited copies r into dst until io.EOF and returns the number of
+// bytes written. When limit is positive no more than limit bytes are
+// written; reading beyond it yields ErrDecompressLimit. A *DefaultByteBuf
+// dst is filled in place so growth via prepare stays bounded by the limit.
+func copyLimited(dst ByteBuf, r io.Reader, limit int) (int, error) {
+	var scratch []byte
+	total := 0
+	for {
+		n := decompressChunk
+		if limit > 0 {
+			if total == limit {
+				var probe [1]byte
+				m, err := io.ReadFull(r, probe[:])
+				if m > 0 {
+					return total, ErrDecompressLimit
+				}
+				if err == io.EOF {
+					return total, nil
+				}
+				return total, err
+			}
+			n = min(n, limit-total)
+		}
+		var m int
+		var err error
+		if b, ok := dst.(*DefaultByteBuf); ok {
+			b.prepare(n)
+			m, err = r.Read(b.buf[b.writerIndex : b.writerIndex+n])
+			b.writerIndex += m
+		} else {
+			if scratch == nil {
+				scratch = make([]byte, decompressChunk)
+			}
+			m, err = r.Read(scratch[:n])
+			dst.WriteBytes(scratch[:m])
+		}
+		total += m
+		if err == io.EOF {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+}
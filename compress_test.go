@@ -0,0 +1,152 @@
+package buf
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var compressInput = bytes.Repeat([]byte("hello compressed byte buf, "), 200)
+
+// Each codec round trips through the one-shot helpers, and the output
+// interoperates with the standard library readers.
+func TestCompress_RoundTrip(t *testing.T) {
+	for _, codec := range []CompressionCodec{CodecGzip, CodecZlib, CodecDeflate} {
+		src := NewCompositeByteBuf(bbBytes(compressInput[:100]), bbBytes(compressInput[100:]))
+		compressed := EmptyByteBuf()
+		assert.NoError(t, CompressTo(compressed, src, codec))
+		assert.Equal(t, 0, src.ReadableBytes())
+		assert.Less(t, compressed.ReadableBytes(), len(compressInput)/4)
+
+		out := EmptyByteBuf()
+		assert.NoError(t, DecompressTo(out, compressed, codec, 0))
+		assert.Equal(t, compressInput, out.Bytes())
+		assert.Equal(t, 0, compressed.ReadableBytes())
+	}
+
+	compressed := EmptyByteBuf()
+	assert.NoError(t, CompressTo(compressed, bbBytes(compressInput), CodecGzip))
+	r, err := gzip.NewReader(bytes.NewReader(compressed.Bytes()))
+	assert.NoError(t, err)
+	plain, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, compressInput, plain)
+
+	assert.ErrorIs(t, CompressTo(EmptyByteBuf(), EmptyByteBuf(), CompressionCodec(9)), ErrUnknownCodec)
+	assert.PanicsWithValue(t, ErrNilObject, func() { CompressTo(nil, EmptyByteBuf(), CompressionCodec(9)) })
+	assert.PanicsWithValue(t, ErrNilObject, func() { DecompressTo(EmptyByteBuf(), nil, CodecGzip, 0) })
+	_, err = NewCompressor(CodecDeflate, 42)
+	assert.Error(t, err)
+}
+
+// Sync-flushed deflate messages decode one by one and share the window,
+// as with permessage-deflate context takeover.
+func TestCompress_StreamingDeflate(t *testing.T) {
+	c, err := NewCompressor(CodecDeflate, flate.BestCompression)
+	assert.NoError(t, err)
+	d, err := NewDecompressor(CodecDeflate, 0)
+	assert.NoError(t, err)
+
+	msgs := []string{"the first message", "the first message again", "and a third one"}
+	var sizes []int
+	for _, m := range msgs {
+		frame, err := c.Compress(bb(m))
+		assert.NoError(t, err)
+		sizes = append(sizes, frame.ReadableBytes())
+		assert.NotEqual(t, []byte{0x00, 0x00, 0xff, 0xff}, frame.Bytes()[frame.ReadableBytes()-4:])
+
+		out, err := d.Decompress(frame)
+		assert.NoError(t, err)
+		assert.Equal(t, m, string(out.Bytes()))
+		ReleaseByteBuf(frame)
+		ReleaseByteBuf(out)
+	}
+	// The second message back-references the first.
+	assert.Less(t, sizes[1], sizes[0])
+
+	tail := EmptyByteBuf()
+	assert.NoError(t, c.Finish(tail))
+	out := EmptyByteBuf()
+	assert.NoError(t, d.DecompressTo(out, tail))
+	assert.Equal(t, 0, out.ReadableBytes())
+}
+
+// Deflate messages follow RFC 7692: frames stripped of their sync flush
+// marker by compress/flate decode, and Compressor frames decode with
+// compress/flate once the marker is appended.
+func TestCompress_DeflateStrippedFrames(t *testing.T) {
+	var raw bytes.Buffer
+	w, err := flate.NewWriter(&raw, flate.DefaultCompression)
+	assert.NoError(t, err)
+	d, err := NewDecompressor(CodecDeflate, 0)
+	assert.NoError(t, err)
+	for _, m := range []string{"first frame", "second frame, first frame"} {
+		raw.Reset()
+		w.Write([]byte(m))
+		assert.NoError(t, w.Flush())
+		frame := raw.Bytes()
+		assert.Equal(t, []byte{0x00, 0x00, 0xff, 0xff}, frame[len(frame)-4:])
+
+		out := EmptyByteBuf()
+		src := bbBytes(frame[:len(frame)-4])
+		assert.NoError(t, d.DecompressTo(out, src))
+		assert.Equal(t, m, string(out.Bytes()))
+		assert.Equal(t, 0, src.ReadableBytes())
+	}
+
+	c, err := NewCompressor(CodecDeflate, flate.DefaultCompression)
+	assert.NoError(t, err)
+	frame := EmptyByteBuf()
+	assert.NoError(t, c.CompressTo(frame, bb("to the standard library")))
+	r := flate.NewReader(io.MultiReader(bytes.NewReader(frame.Bytes()),
+		bytes.NewReader([]byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff})))
+	plain, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "to the standard library", string(plain))
+}
+
+// Each DecompressTo call decodes a single gzip member and leaves the next
+// one in src.
+func TestDecompress_GzipMembers(t *testing.T) {
+	src := EmptyByteBuf()
+	assert.NoError(t, CompressTo(src, bb("one"), CodecGzip))
+	assert.NoError(t, CompressTo(src, bb("two"), CodecGzip))
+
+	d, err := NewDecompressor(CodecGzip, 0)
+	assert.NoError(t, err)
+	for _, m := range []string{"one", "two"} {
+		out := EmptyByteBuf()
+		assert.NoError(t, d.DecompressTo(out, src))
+		assert.Equal(t, m, string(out.Bytes()))
+	}
+	assert.Equal(t, 0, src.ReadableBytes())
+}
+
+// The output limit stops decompression bombs before dst grows past it.
+func TestDecompress_Limit(t *testing.T) {
+	bomb := EmptyByteBuf()
+	assert.NoError(t, CompressTo(bomb, bbBytes(make([]byte, 1<<20)), CodecZlib))
+
+	out := EmptyByteBuf().(*DefaultByteBuf)
+	assert.ErrorIs(t, DecompressTo(out, bomb.Clone(), CodecZlib, 1000), ErrDecompressLimit)
+	assert.Equal(t, 1000, out.ReadableBytes())
+	assert.LessOrEqual(t, out.Cap(), 2048)
+
+	c := NewCompositeByteBuf()
+	assert.ErrorIs(t, DecompressTo(c, bomb.Clone(), CodecZlib, 4096), ErrDecompressLimit)
+	assert.Equal(t, 4096, c.ReadableBytes())
+
+	exact := EmptyByteBuf()
+	assert.NoError(t, DecompressTo(exact, bomb, CodecZlib, 1<<20))
+	assert.Equal(t, 1<<20, exact.ReadableBytes())
+}
+
+// Corrupted input surfaces the decoder error.
+func TestDecompress_Corrupted(t *testing.T) {
+	assert.Error(t, DecompressTo(EmptyByteBuf(), bb("not gzip"), CodecGzip, 0))
+	assert.Error(t, DecompressTo(EmptyByteBuf(), bb("not zlib"), CodecZlib, 0))
+}
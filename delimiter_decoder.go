@@ -0,0 +1,176 @@
+package buf
+
+// LineDelimiters returns the delimiters for line-based protocols: "\r\n"
+// and "\n". "\r\n" is listed first so a CRLF-terminated line never keeps
+// its trailing '\r'.
+func LineDelimiters() [][]byte {
+	return [][]byte{[]byte("\r\n"), []byte("\n")}
+}
+
+// DelimiterConfig describes the framing handled by DelimiterFrameDecoder.
+type DelimiterConfig struct {
+	// MaxFrameLength bounds the length of a frame excluding its delimiter.
+	// Must be positive.
+	MaxFrameLength int
+	// Delimiters lists the byte sequences that terminate a frame. When
+	// several match, the one ending the shortest frame wins; ties go to the
+	// delimiter listed first. Nil selects LineDelimiters.
+	Delimiters [][]byte
+	// KeepDelimiter leaves the delimiter at the end of each frame instead
+	// of stripping it.
+	KeepDelimiter bool
+	// Allocator supplies the cumulation. Nil allocates it directly.
+	Allocator Allocator
+}
+
+// DelimiterFrameDecoder splits a byte stream on one or more delimiters.
+// Incoming ByteBufs are cumulated into a CompositeByteBuf without copying,
+// the delimiter search walks component boundaries in place, and every frame
+// is a ReadSlice view over the cumulation.
+//
+// Added buffers are aliased, so callers must not overwrite them while any
+// frame derived from them is still in use. Close hands the cumulation back
+// to the configured allocator.
+//
+// DelimiterFrameDecoder is NOT goroutine-safe.
+type DelimiterFrameDecoder struct {
+	cfg        DelimiterConfig
+	cumulation CompositeByteBuf
+	discarding bool
+	// maxDelimLen is the length of the longest delimiter. Up to
+	// maxDelimLen-1 trailing bytes may be the start of a delimiter still
+	// arriving, so they are never discarded or counted against
+	// MaxFrameLength before more input comes.
+	maxDelimLen int
+	// scanned is the offset from the reader index before which no
+	// delimiter starts, so a search resumes there instead of at 0.
+	scanned int
+}
+
+// NewDelimiterFrameDecoder returns a decoder for cfg. It panics with
+// ErrInvalidFrameConfig when cfg is not usable.
+func NewDelimiterFrameDecoder(cfg DelimiterConfig) *DelimiterFrameDecoder {
+	if cfg.MaxFrameLength <= 0 {
+		panic(ErrInvalidFrameConfig)
+	}
+	if cfg.Delimiters == nil {
+		cfg.Delimiters = LineDelimiters()
+	}
+	if len(cfg.Delimiters) == 0 {
+		panic(ErrInvalidFrameConfig)
+	}
+	maxDelimLen := 0
+	for _, delim := range cfg.Delimiters {
+		if len(delim) == 0 {
+			panic(ErrInvalidFrameConfig)
+		}
+		maxDelimLen = max(maxDelimLen, len(delim))
+	}
+	return &DelimiterFrameDecoder{
+		cfg:         cfg,
+		cumulation:  compositeFrom(cfg.Allocator),
+		maxDelimLen: maxDelimLen,
+	}
+}
+
+// NewLineFrameDecoder returns a decoder splitting on "\n" and "\r\n" with
+// the line terminator stripped.
+func NewLineFrameDecoder(maxLineLength int) *DelimiterFrameDecoder {
+	return NewDelimiterFrameDecoder(DelimiterConfig{MaxFrameLength: maxLineLength})
+}
+
+// Add appends the readable region of in to the cumulation and marks it as
+// consumed on in.
+func (d *DelimiterFrameDecoder) Add(in ByteBuf) {
+	cumulate(d.cumulation, in)
+}
+
+// Buffered returns the number of cumulated bytes not yet handed out.
+func (d *DelimiterFrameDecoder) Buffered() int {
+	return d.cumulation.ReadableBytes()
+}
+
+// Close releases the cumulation to the configured allocator. Neither the
+// decoder nor the frames it handed out may be used afterwards.
+func (d *DelimiterFrameDecoder) Close() error {
+	releaseComposite(d.cfg.Allocator, d.cumulation)
+	d.discarding = false
+	d.scanned = 0
+	return nil
+}
+
+// Decode adds in and returns every frame that is now complete. A non-nil
+// error is returned together with the frames decoded before it.
+func (d *DelimiterFrameDecoder) Decode(in ByteBuf) ([]ByteBuf, error) {
+	d.Add(in)
+	return drainFrames(d.Next)
+}
+
+// Next returns the next complete frame, or nil when more input is needed.
+// A frame longer than MaxFrameLength is reported with ErrFrameTooLong as
+// soon as that is known, and its bytes are skipped up to and including the
+// next delimiter.
+func (d *DelimiterFrameDecoder) Next() (ByteBuf, error) {
+	defer d.cumulation.Compact()
+	c := d.cumulation
+	frameLen, delimLen := d.findDelimiter()
+	if frameLen < 0 {
+		// The last maxDelimLen-1 bytes may begin a delimiter.
+		partial := d.maxDelimLen - 1
+		if d.discarding {
+			d.skipKeeping(partial)
+			return nil, nil
+		}
+		if c.ReadableBytes() > d.cfg.MaxFrameLength+partial {
+			d.skipKeeping(partial)
+			d.discarding = true
+			return nil, ErrFrameTooLong
+		}
+		return nil, nil
+	}
+	d.scanned = 0
+	if d.discarding {
+		// The oversized frame was already reported; drop its tail and
+		// decode whatever follows.
+		c.Skip(frameLen + delimLen)
+		d.discarding = false
+		return d.Next()
+	}
+	if frameLen > d.cfg.MaxFrameLength {
+		c.Skip(frameLen + delimLen)
+		return nil, ErrFrameTooLong
+	}
+	if d.cfg.KeepDelimiter {
+		return c.ReadSlice(frameLen + delimLen), nil
+	}
+	frame := c.ReadSlice(frameLen)
+	c.Skip(delimLen)
+	return frame, nil
+}
+
+// skipKeeping drops the readable bytes except the last keep.
+func (d *DelimiterFrameDecoder) skipKeeping(keep int) {
+	if n := d.cumulation.ReadableBytes() - keep; n > 0 {
+		d.cumulation.Skip(n)
+		d.scanned = max(0, d.scanned-n)
+	}
+}
+
+// findDelimiter returns the length of the shortest frame terminated by any
+// delimiter and the length of that delimiter, or -1 when none is found.
+// The search starts at scanned, and a miss moves scanned up to the first
+// offset a delimiter completed by later input could start at.
+func (d *DelimiterFrameDecoder) findDelimiter() (frameLen, delimLen int) {
+	frameLen = -1
+	for _, delim := range d.cfg.Delimiters {
+		i := indexOf(d.cumulation, d.scanned, delim)
+		if i >= 0 && (frameLen < 0 || i < frameLen) {
+			frameLen = i
+			delimLen = len(delim)
+		}
+	}
+	if frameLen < 0 {
+		d.scanned = max(d.scanned, d.cumulation.ReadableBytes()-d.maxDelimLen+1)
+	}
+	return frameLen, delimLen
+}
@@ -0,0 +1,143 @@
+package buf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Lines terminated by "\n" or "\r\n" are split and stripped.
+func TestDelimiterDecoder_Lines(t *testing.T) {
+	d := NewLineFrameDecoder(64)
+	frames, err := d.Decode(NewByteBuf([]byte("HELO a\r\nMAIL b\nRCPT")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"HELO a", "MAIL b"}, frameStrings(frames))
+	assert.Equal(t, 4, d.Buffered())
+
+	frames, err = d.Decode(NewByteBuf([]byte(" c\r\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"RCPT c"}, frameStrings(frames))
+}
+
+// A delimiter split across components is still found, and the composite is
+// never consolidated during the search.
+func TestDelimiterDecoder_DelimiterAcrossComponents(t *testing.T) {
+	d := NewDelimiterFrameDecoder(DelimiterConfig{
+		MaxFrameLength: 64,
+		Delimiters:     [][]byte{[]byte("||")},
+		KeepDelimiter:  true,
+	})
+	d.Add(NewByteBuf([]byte("ab|")))
+	d.Add(NewByteBuf([]byte("|cd|")))
+	assert.Equal(t, 2, len(asDefault(d.cumulation).components))
+
+	frames, err := drainFrames(d.Next)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ab||"}, frameStrings(frames))
+	assert.Equal(t, 3, d.Buffered())
+}
+
+// Among several delimiters the one yielding the shortest frame wins.
+func TestDelimiterDecoder_ShortestFrameWins(t *testing.T) {
+	d := NewDelimiterFrameDecoder(DelimiterConfig{
+		MaxFrameLength: 64,
+		Delimiters:     [][]byte{[]byte(";"), []byte(",")},
+	})
+	frames, err := d.Decode(NewByteBuf([]byte("a,b;c;")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, frameStrings(frames))
+}
+
+// Oversized lines are reported once and skipped through their delimiter,
+// including when the delimiter arrives in a later chunk.
+func TestDelimiterDecoder_TooLong(t *testing.T) {
+	d := NewLineFrameDecoder(4)
+	frames, err := d.Decode(NewByteBuf([]byte("toolong\nok\n")))
+	assert.ErrorIs(t, err, ErrFrameTooLong)
+	assert.Empty(t, frames)
+	frames, err = drainFrames(d.Next)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ok"}, frameStrings(frames))
+
+	_, err = d.Decode(NewByteBuf([]byte("abcdefgh")))
+	assert.ErrorIs(t, err, ErrFrameTooLong)
+	assert.Equal(t, 1, d.Buffered(), "may start a \r\n")
+	frames, err = d.Decode(NewByteBuf([]byte("ij\nnext\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"next"}, frameStrings(frames))
+}
+
+// A "\r\n" split across two inputs ends a frame of exactly the maximum
+// length instead of failing it early.
+func TestDelimiterDecoder_SplitCRLFAtMaxLength(t *testing.T) {
+	d := NewLineFrameDecoder(4)
+	frames, err := d.Decode(NewByteBuf([]byte("abcd\r")))
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+	frames, err = d.Decode(NewByteBuf([]byte("\nefgh\r")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abcd"}, frameStrings(frames))
+	frames, err = d.Decode(NewByteBuf([]byte("\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"efgh"}, frameStrings(frames))
+
+	_, err = d.Decode(NewByteBuf([]byte("abcde\r")))
+	assert.ErrorIs(t, err, ErrFrameTooLong)
+}
+
+// A "\r\n" split across two inputs still ends an oversized frame being
+// discarded, and the frame after it survives.
+func TestDelimiterDecoder_SplitCRLFWhileDiscarding(t *testing.T) {
+	d := NewLineFrameDecoder(4)
+	_, err := d.Decode(NewByteBuf([]byte("toolong")))
+	assert.ErrorIs(t, err, ErrFrameTooLong)
+	frames, err := d.Decode(NewByteBuf([]byte("more\r")))
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+	assert.Equal(t, 1, d.Buffered())
+	frames, err = d.Decode(NewByteBuf([]byte("\nok\r\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ok"}, frameStrings(frames))
+}
+
+// A miss remembers how far the search got, so slow input is not rescanned.
+func TestDelimiterDecoder_ResumesSearch(t *testing.T) {
+	d := NewLineFrameDecoder(64)
+	for _, chunk := range []string{"ab", "cd", "e\r"} {
+		frames, err := d.Decode(NewByteBuf([]byte(chunk)))
+		assert.NoError(t, err)
+		assert.Empty(t, frames)
+	}
+	assert.Equal(t, 5, d.scanned)
+	frames, err := d.Decode(NewByteBuf([]byte("\n")))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abcde"}, frameStrings(frames))
+	assert.Equal(t, 0, d.scanned)
+}
+
+// Close hands the cumulation and its tails back to the allocator.
+func TestDelimiterDecoder_CloseReleasesCumulation(t *testing.T) {
+	alloc := NewTrackingAllocator(Pooled)
+	d := NewDelimiterFrameDecoder(DelimiterConfig{MaxFrameLength: 64, Allocator: alloc})
+	d.cumulation.WriteString("partial")
+	assert.NotZero(t, alloc.Outstanding())
+	assert.NoError(t, d.Close())
+	assert.Zero(t, alloc.Outstanding())
+}
+
+// Frames alias the input instead of copying it.
+func TestDelimiterDecoder_ZeroCopy(t *testing.T) {
+	d := NewLineFrameDecoder(64)
+	src := []byte("abc\n")
+	frames, err := d.Decode(NewSharedByteBuf(src))
+	assert.NoError(t, err)
+	src[0] = 'A'
+	assert.Equal(t, "Abc", string(frames[0].BytesCopy()))
+}
+
+func TestDelimiterDecoder_InvalidConfig_Panics(t *testing.T) {
+	assert.Panics(t, func() { NewLineFrameDecoder(0) })
+	assert.Panics(t, func() {
+		NewDelimiterFrameDecoder(DelimiterConfig{MaxFrameLength: 1, Delimiters: [][]byte{{}}})
+	})
+}
@@ -0,0 +1,108 @@
+package buf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrFrameTooLong is returned by frame decoders when a frame exceeds the
+// configured maximum length. The offending frame is discarded and decoding
+// resumes with the bytes that follow it.
+var ErrFrameTooLong = errors.New("frame too long")
+
+// ErrCorruptedFrame is returned when a length field decodes to a frame that
+// cannot be valid, e.g. one shorter than its own header.
+var ErrCorruptedFrame = errors.New("corrupted frame")
+
+// ErrInvalidFrameConfig is raised when a frame codec is constructed with an
+// unsupported configuration.
+var ErrInvalidFrameConfig = errors.New("invalid frame config")
+
+// FrameDecoder is implemented by the stream decoders that cut frames out of
+// cumulated input.
+type FrameDecoder interface {
+	// Add cumulates the readable region of in without copying and marks it
+	// as consumed on in.
+	Add(in ByteBuf)
+	// Next returns the next complete frame, or nil when more input is
+	// needed.
+	Next() (ByteBuf, error)
+	// Decode is Add followed by Next until no complete frame remains.
+	Decode(in ByteBuf) ([]ByteBuf, error)
+	// Buffered returns the number of cumulated bytes not yet handed out.
+	Buffered() int
+}
+
+var (
+	_ FrameDecoder = (*LengthFieldFrameDecoder)(nil)
+	_ FrameDecoder = (*DelimiterFrameDecoder)(nil)
+)
+
+// cumulate appends in's readable region to dst as an aliased component and
+// advances in past it.
+func cumulate(dst CompositeByteBuf, in ByteBuf) {
+	if in == nil {
+		panic(ErrNilObject)
+	}
+	n := in.ReadableBytes()
+	if n == 0 {
+		return
+	}
+	dst.AddComponent(in)
+	in.Skip(n)
+}
+
+// drainFrames calls next until it yields no frame, collecting the frames.
+// A non-nil error is returned together with the frames decoded before it.
+func drainFrames(next func() (ByteBuf, error)) ([]ByteBuf, error) {
+	var frames []ByteBuf
+	for {
+		frame, err := next()
+		if err != nil {
+			return frames, err
+		}
+		if frame == nil {
+			return frames, nil
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// isLittleEndian reports whether order stores the least significant byte
+// first.
+func isLittleEndian(order binary.ByteOrder) bool {
+	return order.Uint16([]byte{1, 0}) == 1
+}
+
+// getBytes copies len(dst) bytes at the absolute index idx of bb, which
+// must lie in the readable region, into dst. It goes through
+// AbsoluteAccessor when bb implements it.
+func getBytes(bb ByteBuf, idx int, dst []byte) {
+	if a, ok := bb.(AbsoluteAccessor); ok {
+		a.GetBytes(idx, dst)
+		return
+	}
+	copy(dst, bb.Bytes()[idx-bb.ReaderIndex():])
+}
+
+// indexOf returns the offset of sep within bb's readable region, searching
+// from the given offset, or -1. Composites are scanned component by
+// component so the search never consolidates them.
+func indexOf(bb ByteBuf, from int, sep []byte) int {
+	switch b := bb.(type) {
+	case *defaultCompositeByteBuf:
+		return b.indexOf(from, sep)
+	case *DefaultByteBuf:
+		if i := bytes.Index(b.Bytes()[from:], sep); i >= 0 {
+			return from + i
+		}
+		return -1
+	case OrderedByteBuf:
+		return indexOf(b.Unwrap(), from, sep)
+	}
+	if i := bytes.Index(bb.BytesCopy()[from:], sep); i >= 0 {
+		return from + i
+	}
+	return -1
+}
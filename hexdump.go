@@ -0,0 +1,164 @@
+package buf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// logValueMaxBytes caps the number of readable bytes rendered by LogValue.
+const logValueMaxBytes = 64
+
+var (
+	_ fmt.Formatter  = (*DefaultByteBuf)(nil)
+	_ fmt.Formatter  = (*defaultCompositeByteBuf)(nil)
+	_ slog.LogValuer = (*DefaultByteBuf)(nil)
+	_ slog.LogValuer = (*defaultCompositeByteBuf)(nil)
+)
+
+// HexDump renders the readable region of bb as a table of offset, hex and
+// ASCII columns. It is PrettyHexDump over [ReaderIndex(), WriterIndex()).
+func HexDump(bb ByteBuf) string {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	return PrettyHexDump(bb, bb.ReaderIndex(), bb.ReadableBytes())
+}
+
+// PrettyHexDump renders length bytes of bb starting at the absolute index
+// from, using the index space of the AbsoluteAccessor getters. Offsets in the
+// first column are absolute indices. On a composite each component starts a
+// new row block so the component layout stays visible. Neither the indices
+// nor the component layout of bb are changed. It panics with
+// ErrInsufficientSize when the range is outside [0, WriterIndex()).
+func PrettyHexDump(bb ByteBuf, from, length int) string {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	checkRange(bb, from, length)
+	if length == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("         +-------------------------------------------------+\n")
+	sb.WriteString("         |  0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f |\n")
+	const rule = "+--------+-------------------------------------------------+----------------+\n"
+	sb.WriteString(rule)
+	cur := newSegmentCursor(bb, from, length)
+	for start, seg := cur.next(); seg != nil; start, seg = cur.next() {
+		for off := 0; off < len(seg); off += 16 {
+			writeHexRow(&sb, start+off, seg[off:min(off+16, len(seg))])
+		}
+		sb.WriteString(rule)
+	}
+	return sb.String()
+}
+
+func writeHexRow(sb *strings.Builder, offset int, row []byte) {
+	var line [78]byte
+	copy(line[:], "|00000000|                                                 |                |\n")
+	hex.Encode(line[1:9], []byte{byte(offset >> 24), byte(offset >> 16), byte(offset >> 8), byte(offset)})
+	for i, c := range row {
+		hex.Encode(line[11+3*i:13+3*i], []byte{c})
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		line[60+i] = c
+	}
+	sb.Write(line[:])
+}
+
+// describe returns a one-line summary of bb's indices; verbose adds the
+// refcount and, for composites, the component boundaries.
+func describe(bb ByteBuf, verbose bool) string {
+	var sb strings.Builder
+	comp, isComposite := bb.(*defaultCompositeByteBuf)
+	if isComposite {
+		sb.WriteString("CompositeByteBuf")
+	} else {
+		sb.WriteString("DefaultByteBuf")
+	}
+	fmt.Fprintf(&sb, "(ridx: %d, widx: %d, cap: %d", bb.ReaderIndex(), bb.WriterIndex(), bb.Cap())
+	if verbose {
+		if rc, ok := bb.(RefCounted); ok {
+			fmt.Fprintf(&sb, ", refcnt: %d", rc.RefCnt())
+		}
+		if isComposite {
+			sb.WriteString(", components: [")
+			start := 0
+			for i, c := range comp.components {
+				if i > 0 {
+					sb.WriteByte(' ')
+				}
+				sb.WriteString("[" + strconv.Itoa(start) + "," + strconv.Itoa(c.endOffset) + ")")
+				start = c.endOffset
+			}
+			sb.WriteByte(']')
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// formatByteBuf implements fmt.Formatter for the ByteBufs of this package.
+// %s, %q, %x and %X format the readable bytes like a []byte; %v prints the
+// indices and %+v adds the refcount and component boundaries.
+func formatByteBuf(f fmt.State, verb rune, bb ByteBuf) {
+	switch verb {
+	case 's', 'q', 'x', 'X':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), firstBytes(bb, bb.ReadableBytes()))
+	case 'v':
+		fmt.Fprint(f, describe(bb, f.Flag('+')))
+	default:
+		fmt.Fprintf(f, "%%!%c(%s)", verb, describe(bb, false))
+	}
+}
+
+// logValue renders bb for slog, truncating the hex payload to
+// logValueMaxBytes.
+func logValue(bb ByteBuf) slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("ridx", bb.ReaderIndex()),
+		slog.Int("widx", bb.WriterIndex()),
+		slog.Int("cap", bb.Cap()),
+	}
+	if c, ok := bb.(*defaultCompositeByteBuf); ok {
+		attrs = append(attrs, slog.Int("components", len(c.components)))
+	}
+	n := min(bb.ReadableBytes(), logValueMaxBytes)
+	attrs = append(attrs, slog.String("hex", hex.EncodeToString(firstBytes(bb, n))))
+	if n < bb.ReadableBytes() {
+		attrs = append(attrs, slog.Bool("truncated", true))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// firstBytes returns the first n readable bytes of bb without moving indices.
+func firstBytes(bb ByteBuf, n int) []byte {
+	cur := newSegmentCursor(bb, bb.ReaderIndex(), n)
+	_, first := cur.next()
+	if len(first) == n {
+		return first
+	}
+	out := append(make([]byte, 0, n), first...)
+	for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+		out = append(out, seg...)
+	}
+	return out
+}
+
+// Format implements fmt.Formatter; see formatByteBuf for the verbs.
+func (b *DefaultByteBuf) Format(f fmt.State, verb rune) { formatByteBuf(f, verb, b) }
+
+// LogValue implements slog.LogValuer, reporting the indices and at most
+// the first 64 readable bytes in hex.
+func (b *DefaultByteBuf) LogValue() slog.Value { return logValue(b) }
+
+// Format implements fmt.Formatter; see formatByteBuf for the verbs.
+func (c *defaultCompositeByteBuf) Format(f fmt.State, verb rune) { formatByteBuf(f, verb, c) }
+
+// LogValue implements slog.LogValuer, reporting the indices, the component
+// count and at most the first 64 readable bytes in hex.
+func (c *defaultCompositeByteBuf) LogValue() slog.Value { return logValue(c) }
@@ -0,0 +1,79 @@
+package buf
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHexDump_Default(t *testing.T) {
+	b := bb("xxHello, world!\x00\x01 and more")
+	b.Skip(2)
+	want := "" +
+		"         +-------------------------------------------------+\n" +
+		"         |  0  1  2  3  4  5  6  7  8  9  a  b  c  d  e  f |\n" +
+		"+--------+-------------------------------------------------+----------------+\n" +
+		"|00000002| 48 65 6c 6c 6f 2c 20 77 6f 72 6c 64 21 00 01 20 |Hello, world!.. |\n" +
+		"|00000012| 61 6e 64 20 6d 6f 72 65                         |and more        |\n" +
+		"+--------+-------------------------------------------------+----------------+\n"
+	assert.Equal(t, want, HexDump(b))
+	assert.Equal(t, 2, b.ReaderIndex())
+
+	assert.Contains(t, PrettyHexDump(b, 0, 3), "|00000000| 78 78 48 ")
+	assert.Equal(t, "", PrettyHexDump(b, 5, 0))
+	assert.Panics(t, func() { PrettyHexDump(b, 20, 10) })
+}
+
+// Composite dumps start a new block per component and never consolidate.
+func TestHexDump_Composite(t *testing.T) {
+	c := NewCompositeByteBuf(bb("abc"), bb("defg"))
+	c.WriteString("h")
+	c.Skip(1)
+	before := len(asDefault(c).components)
+
+	dump := HexDump(c)
+	assert.Equal(t, 4, strings.Count(dump, "+--------+"))
+	assert.Contains(t, dump, "|00000001| 62 63                                           |bc              |\n+--------+")
+	assert.Contains(t, dump, "|00000003| 64 65 66 67                                     |defg            |")
+	assert.Contains(t, dump, "|00000007| 68 ")
+	assert.Equal(t, before, len(asDefault(c).components))
+	assert.Equal(t, 1, c.ReaderIndex())
+}
+
+func TestFormat_Verbs(t *testing.T) {
+	b := bb("hi\n")
+	assert.Equal(t, "68690a", fmt.Sprintf("%x", b))
+	assert.Equal(t, "68 69 0A", fmt.Sprintf("% X", b))
+	assert.Equal(t, `"hi\n"`, fmt.Sprintf("%q", b))
+	assert.Equal(t, "hi\n", fmt.Sprintf("%s", b))
+	assert.Equal(t, "DefaultByteBuf(ridx: 0, widx: 3, cap: 3)", fmt.Sprintf("%v", b))
+	assert.Equal(t, "DefaultByteBuf(ridx: 0, widx: 3, cap: 3, refcnt: 1)", fmt.Sprintf("%+v", b))
+
+	c := NewCompositeByteBuf(bb("ab"), bb("cd"))
+	c.Skip(1)
+	assert.Equal(t, "626364", fmt.Sprintf("%x", c))
+	assert.Equal(t, "CompositeByteBuf(ridx: 1, widx: 4, cap: 4, refcnt: 1, components: [[0,2) [2,4)])", fmt.Sprintf("%+v", c))
+	assert.Equal(t, 2, len(asDefault(c).components))
+	assert.Equal(t, 1, c.ReaderIndex())
+}
+
+func TestLogValue_Truncates(t *testing.T) {
+	var out bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&out, nil))
+
+	log.Info("small", "buf", bb("ab"))
+	assert.Contains(t, out.String(), "buf.ridx=0 buf.widx=2 buf.cap=2 buf.hex=6162\n")
+
+	out.Reset()
+	big := NewCompositeByteBuf(bbBytes(make([]byte, 50)), bbBytes(bytes.Repeat([]byte{0xff}, 50)))
+	log.Info("big", "buf", big)
+	line := out.String()
+	assert.Contains(t, line, "buf.components=2")
+	assert.Contains(t, line, "buf.hex="+strings.Repeat("00", 50)+strings.Repeat("ff", 14)+" ")
+	assert.Contains(t, line, "buf.truncated=true")
+	assert.Equal(t, 2, len(asDefault(big).components))
+}
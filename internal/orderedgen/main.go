@@ -0,0 +1,166 @@
+// Command orderedgen writes ordered_methods.go, the forwarding methods of
+// the views returned by buf.Order. It runs from the package directory
+// through the go:generate line in ordered.go.
+//
+// Every view type overrides the ByteBuf methods that must keep the view:
+// chained calls return the view, derived buffers are wrapped in the same
+// order, and unsuffixed multi-byte accessors dispatch on the order. The
+// remaining methods are promoted from the embedded buffer.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+)
+
+const output = "ordered_methods.go"
+
+// numerics are the multi-byte types that have an LE-suffixed twin.
+var numerics = []struct{ name, typ string }{
+	{"Int16", "int16"},
+	{"Int32", "int32"},
+	{"Int64", "int64"},
+	{"UInt16", "uint16"},
+	{"UInt32", "uint32"},
+	{"UInt64", "uint64"},
+	{"Float32", "float32"},
+	{"Float64", "float64"},
+}
+
+// method is one forwarded method. params and args are the parameter list
+// and the argument list passed on.
+type method struct{ name, params, args string }
+
+// chained methods return the receiver instead of the wrapped buffer.
+var byteBufChained = []method{
+	{"MarkReaderIndex", "", ""},
+	{"ResetReaderIndex", "", ""},
+	{"MarkWriterIndex", "", ""},
+	{"ResetWriterIndex", "", ""},
+	{"Reset", "", ""},
+	{"Compact", "", ""},
+	{"Grow", "v int", "v"},
+	{"EnsureCapacity", "n int", "n"},
+	{"Skip", "v int", "v"},
+	{"AppendByte", "c byte", "c"},
+	{"WriteBytes", "bs []byte", "bs"},
+	{"WriteString", "s string", "s"},
+	{"WriteByteBuf", "buf ByteBuf", "buf"},
+	{"WriteReader", "reader io.Reader", "reader"},
+	{"ReadWriter", "writer io.Writer", "writer"},
+}
+
+// derived methods return a new buffer that gets the receiver's order.
+var byteBufDerived = []method{
+	{"Clone", "", ""},
+	{"ReadByteBuf", "n int", "n"},
+}
+
+var capsChained = []method{
+	{"Retain", "", ""},
+	{"GetBytes", "idx int, dst []byte", "idx, dst"},
+	{"SetByte", "idx int, v byte", "idx, v"},
+	{"SetBytes", "idx int, src []byte", "idx, src"},
+	{"WriteVarUInt32", "v uint32", "v"},
+	{"WriteVarUInt64", "v uint64", "v"},
+	{"WriteVarInt32", "v int32", "v"},
+	{"WriteVarInt64", "v int64", "v"},
+}
+
+var capsDerived = []method{
+	{"Slice", "from, length int", "from, length"},
+	{"Duplicate", "", ""},
+	{"ReadSlice", "n int", "n"},
+	{"RetainedSlice", "from, length int", "from, length"},
+	{"RetainedDuplicate", "", ""},
+	{"ReadRetainedSlice", "n int", "n"},
+}
+
+type generator struct{ buf bytes.Buffer }
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func main() {
+	src, err := generate()
+	if err == nil {
+		err = os.WriteFile(output, src, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "orderedgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func generate() ([]byte, error) {
+	g := &generator{}
+	g.printf("// Code generated by orderedgen. DO NOT EDIT.\n\npackage buf\n\nimport \"io\"\n")
+
+	g.byteBufMethods("orderedByteBuf", "ByteBuf")
+	g.byteBufMethods("orderedCapsByteBuf", "orderedCaps")
+
+	const recv, inner = "orderedCapsByteBuf", "orderedCaps"
+	for _, m := range capsChained {
+		g.chained(recv, inner, m)
+	}
+	for _, m := range capsDerived {
+		g.derived(recv, inner, m)
+	}
+	g.printf("\nfunc (o *%s) TryReadByteBuf(n int) (ByteBuf, error) {\n", recv)
+	g.printf("bb, err := o.%s.TryReadByteBuf(n)\nif err != nil {\nreturn nil, err\n}\nreturn o.wrap(bb), nil\n}\n", inner)
+	for _, n := range numerics {
+		g.orderedValue(recv, inner, "TryRead"+n.name, "", "", "("+n.typ+", error)")
+	}
+	for _, n := range numerics {
+		g.orderedValue(recv, inner, "Get"+n.name, "idx int", "idx", n.typ)
+	}
+	for _, n := range numerics {
+		g.chained(recv, inner, method{"Set" + n.name + "LE", "idx int, v " + n.typ, "idx, v"})
+	}
+	for _, n := range numerics {
+		g.orderedChained(recv, inner, "Set"+n.name, "idx int, v "+n.typ, "idx, v")
+	}
+	return format.Source(g.buf.Bytes())
+}
+
+// byteBufMethods writes the ByteBuf overrides of recv.
+func (g *generator) byteBufMethods(recv, inner string) {
+	for _, m := range byteBufChained {
+		g.chained(recv, inner, m)
+	}
+	for _, n := range numerics {
+		g.chained(recv, inner, method{"Write" + n.name + "LE", "v " + n.typ, "v"})
+	}
+	for _, m := range byteBufDerived {
+		g.derived(recv, inner, m)
+	}
+	for _, n := range numerics {
+		g.orderedChained(recv, inner, "Write"+n.name, "v "+n.typ, "v")
+	}
+	for _, n := range numerics {
+		g.orderedValue(recv, inner, "Read"+n.name, "", "", n.typ)
+	}
+}
+
+func (g *generator) chained(recv, inner string, m method) {
+	g.printf("\nfunc (o *%s) %s(%s) ByteBuf {\no.%s.%s(%s)\nreturn o\n}\n",
+		recv, m.name, m.params, inner, m.name, m.args)
+}
+
+func (g *generator) derived(recv, inner string, m method) {
+	g.printf("\nfunc (o *%s) %s(%s) ByteBuf {\nreturn o.wrap(o.%s.%s(%s))\n}\n",
+		recv, m.name, m.params, inner, m.name, m.args)
+}
+
+func (g *generator) orderedChained(recv, inner, name, params, args string) {
+	g.printf("\nfunc (o *%s) %s(%s) ByteBuf {\nif o.le {\no.%s.%sLE(%s)\n} else {\no.%s.%s(%s)\n}\nreturn o\n}\n",
+		recv, name, params, inner, name, args, inner, name, args)
+}
+
+func (g *generator) orderedValue(recv, inner, name, params, args, results string) {
+	g.printf("\nfunc (o *%s) %s(%s) %s {\nif o.le {\nreturn o.%s.%sLE(%s)\n}\nreturn o.%s.%s(%s)\n}\n",
+		recv, name, params, results, inner, name, args, inner, name, args)
+}
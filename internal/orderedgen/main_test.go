@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The committed ordered_methods.go is up to date with the generator.
+func TestGenerate_UpToDate(t *testing.T) {
+	src, err := generate()
+	assert.NoError(t, err)
+	want, err := os.ReadFile(filepath.Join("..", "..", output))
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(src))
+}
@@ -0,0 +1,212 @@
+package buf
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrInvalidOffset is returned by ReadAt for a negative offset and by Seek
+// for a target before the stream start or past WriterIndex().
+var ErrInvalidOffset = errors.New("invalid offset")
+
+// ErrInvalidWhence is returned by Seek for a whence other than
+// io.SeekStart, io.SeekCurrent and io.SeekEnd.
+var ErrInvalidWhence = errors.New("invalid whence")
+
+// ErrInvalidUnread is returned by UnreadByte at index 0 and by UnreadRune
+// when the previous read was not a ReadRune.
+var ErrInvalidUnread = errors.New("invalid unread")
+
+var (
+	_ io.ReaderAt    = (*DefaultByteBuf)(nil)
+	_ io.ReadSeeker  = (*DefaultByteBuf)(nil)
+	_ io.ByteScanner = (*DefaultByteBuf)(nil)
+	_ io.RuneScanner = (*DefaultByteBuf)(nil)
+	_ io.ReaderAt    = (*defaultCompositeByteBuf)(nil)
+	_ io.ReadSeeker  = (*defaultCompositeByteBuf)(nil)
+	_ io.ByteScanner = (*defaultCompositeByteBuf)(nil)
+	_ io.RuneScanner = (*defaultCompositeByteBuf)(nil)
+)
+
+// readAt implements io.ReaderAt over the readable region of bb: off 0 is
+// ReaderIndex(). Indices and the composite lookup cache are left alone, so
+// concurrent ReadAt calls are safe as long as nothing else mutates bb.
+func readAt(bb ByteBuf, p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, ErrInvalidOffset
+	}
+	avail := bb.ReadableBytes()
+	if off >= int64(avail) {
+		return 0, io.EOF
+	}
+	n := min(len(p), avail-int(off))
+	cur := newSegmentCursor(bb, bb.ReaderIndex()+int(off), n)
+	copied := 0
+	for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+		copied += copy(p[copied:], seg)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// seekTarget resolves a Seek request against the readable region
+// [ridx, widx) and returns the new reader index.
+func seekTarget(ridx, widx int, offset int64, whence int) (int, error) {
+	var base int64
+	switch whence {
+	case io.SeekStart, io.SeekCurrent:
+		base = int64(ridx)
+	case io.SeekEnd:
+		base = int64(widx)
+	default:
+		return 0, ErrInvalidWhence
+	}
+	target := base + offset
+	if target < int64(ridx) || target > int64(widx) {
+		return 0, ErrInvalidOffset
+	}
+	return int(target), nil
+}
+
+// decodeRune decodes the rune at the start of p, which holds at most
+// utf8.UTFMax bytes. Invalid encodings decode as utf8.RuneError of size 1.
+func decodeRune(p []byte) (rune, int) {
+	if p[0] < utf8.RuneSelf {
+		return rune(p[0]), 1
+	}
+	return utf8.DecodeRune(p)
+}
+
+// ---------- DefaultByteBuf ----------
+
+// ReadAt implements io.ReaderAt. off is relative to the readable region,
+// so off 0 is ReaderIndex(); no index is moved. A buffer handed to
+// zip.NewReader together with ReadableBytes() is read without copying.
+func (b *DefaultByteBuf) ReadAt(p []byte, off int64) (int, error) {
+	return readAt(b, p, off)
+}
+
+// Seek implements io.Seeker by moving readerIndex. Like ReadAt, it works
+// on the readable region, so position 0 is ReaderIndex() at the time of
+// the call and io.SeekStart and io.SeekCurrent both count from there;
+// io.SeekEnd is at WriterIndex(). The returned position is the number of
+// bytes skipped. Seeking before ReaderIndex() or past WriterIndex()
+// returns ErrInvalidOffset and leaves readerIndex unchanged; use
+// MarkReaderIndex and ResetReaderIndex to rewind. Marks are left alone.
+func (b *DefaultByteBuf) Seek(offset int64, whence int) (int64, error) {
+	target, err := seekTarget(b.readerIndex, b.writerIndex, offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	pos := target - b.readerIndex
+	b.readerIndex = target
+	b.lastRune = 0
+	return int64(pos), nil
+}
+
+// UnreadByte implements io.ByteScanner by moving readerIndex back one
+// byte. It returns ErrInvalidUnread at index 0.
+func (b *DefaultByteBuf) UnreadByte() error {
+	if b.readerIndex == 0 {
+		return ErrInvalidUnread
+	}
+	b.readerIndex--
+	b.lastRune = 0
+	return nil
+}
+
+// ReadRune implements io.RuneReader, decoding one UTF-8 encoded rune.
+// Invalid encodings yield utf8.RuneError with size 1. It returns io.EOF
+// when nothing is readable.
+func (b *DefaultByteBuf) ReadRune() (rune, int, error) {
+	if b.readerIndex >= b.writerIndex {
+		if b.released() {
+			return 0, 0, ErrBufferReleased
+		}
+		return 0, 0, io.EOF
+	}
+	r, size := decodeRune(b.buf[b.readerIndex:min(b.readerIndex+utf8.UTFMax, b.writerIndex)])
+	b.lastRune = b.readerIndex + 1
+	b.readerIndex += size
+	return r, size, nil
+}
+
+// UnreadRune implements io.RuneScanner. It is only valid directly after
+// ReadRune and returns ErrInvalidUnread otherwise.
+func (b *DefaultByteBuf) UnreadRune() error {
+	start := b.lastRune - 1
+	if start < 0 || start >= b.readerIndex {
+		return ErrInvalidUnread
+	}
+	if _, size := decodeRune(b.buf[start:min(start+utf8.UTFMax, b.writerIndex)]); start+size != b.readerIndex {
+		return ErrInvalidUnread
+	}
+	b.readerIndex = start
+	b.lastRune = 0
+	return nil
+}
+
+// ---------- defaultCompositeByteBuf ----------
+
+// ReadAt implements io.ReaderAt. off is relative to the readable region,
+// so off 0 is ReaderIndex(); reads span component boundaries and neither
+// the indices nor the component layout change.
+func (c *defaultCompositeByteBuf) ReadAt(p []byte, off int64) (int, error) {
+	return readAt(c, p, off)
+}
+
+// Seek implements io.Seeker; see DefaultByteBuf.Seek.
+func (c *defaultCompositeByteBuf) Seek(offset int64, whence int) (int64, error) {
+	target, err := seekTarget(c.readerIdx, c.writerIdx, offset, whence)
+	if err != nil {
+		return 0, err
+	}
+	pos := target - c.readerIdx
+	c.readerIdx = target
+	c.lastRune = 0
+	return int64(pos), nil
+}
+
+// UnreadByte implements io.ByteScanner by moving readerIdx back one byte.
+// It returns ErrInvalidUnread at index 0.
+func (c *defaultCompositeByteBuf) UnreadByte() error {
+	if c.readerIdx == 0 {
+		return ErrInvalidUnread
+	}
+	c.readerIdx--
+	c.lastRune = 0
+	return nil
+}
+
+// ReadRune implements io.RuneReader. A rune whose encoding straddles a
+// component boundary is decoded as one rune. It returns io.EOF when
+// nothing is readable.
+func (c *defaultCompositeByteBuf) ReadRune() (rune, int, error) {
+	if c.readerIdx >= c.writerIdx {
+		return 0, 0, io.EOF
+	}
+	var p [utf8.UTFMax]byte
+	r, size := decodeRune(p[:c.copyAt(c.readerIdx, p[:])])
+	c.lastRune = c.readerIdx + 1
+	c.readerIdx += size
+	return r, size, nil
+}
+
+// UnreadRune implements io.RuneScanner. It is only valid directly after
+// ReadRune and returns ErrInvalidUnread otherwise.
+func (c *defaultCompositeByteBuf) UnreadRune() error {
+	start := c.lastRune - 1
+	if start < 0 || start >= c.readerIdx {
+		return ErrInvalidUnread
+	}
+	var p [utf8.UTFMax]byte
+	if _, size := decodeRune(p[:c.copyAt(start, p[:])]); start+size != c.readerIdx {
+		return ErrInvalidUnread
+	}
+	c.readerIdx = start
+	c.lastRune = 0
+	return nil
+}
@@ -0,0 +1,255 @@
+package buf
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// splitComposite returns a composite over s cut at the given offsets.
+func splitComposite(s []byte, cuts ...int) CompositeByteBuf {
+	c := NewCompositeByteBuf()
+	prev := 0
+	for _, cut := range append(cuts, len(s)) {
+		c.AddComponent(bbBytes(s[prev:cut]))
+		prev = cut
+	}
+	return c
+}
+
+func TestReadAt_RelativeToReaderIndex(t *testing.T) {
+	for _, b := range []ByteBuf{bb("xxhello world"), splitComposite([]byte("xxhello world"), 4, 8)} {
+		b.Skip(2)
+		p := make([]byte, 5)
+		n, err := b.(io.ReaderAt).ReadAt(p, 6)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, n)
+		assert.Equal(t, "world", string(p))
+
+		n, err = b.(io.ReaderAt).ReadAt(p, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "ello ", string(p[:n]))
+
+		n, err = b.(io.ReaderAt).ReadAt(p, 8)
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, "rld", string(p[:n]))
+
+		n, err = b.(io.ReaderAt).ReadAt(p, 11)
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, 0, n)
+
+		_, err = b.(io.ReaderAt).ReadAt(p, -1)
+		assert.ErrorIs(t, err, ErrInvalidOffset)
+
+		assert.Equal(t, 2, b.ReaderIndex())
+		assert.Equal(t, "hello world", string(b.BytesCopy()))
+	}
+}
+
+// A zip archive received in pieces is opened straight from the composite.
+func TestReadAt_ZipFromComposite(t *testing.T) {
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, err := zw.Create("greeting.txt")
+	assert.NoError(t, err)
+	_, err = w.Write(bytes.Repeat([]byte("hello zip "), 100))
+	assert.NoError(t, err)
+	assert.NoError(t, zw.Close())
+
+	raw := archive.Bytes()
+	c := splitComposite(raw, 7, len(raw)/2, len(raw)-9)
+	zr, err := zip.NewReader(c.(io.ReaderAt), int64(c.ReadableBytes()))
+	assert.NoError(t, err)
+	assert.Len(t, zr.File, 1)
+	rc, err := zr.File[0].Open()
+	assert.NoError(t, err)
+	got, err := io.ReadAll(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte("hello zip "), 100), got)
+	assert.Equal(t, len(raw), c.ReadableBytes())
+}
+
+// Seek positions count from ReaderIndex(), like ReadAt offsets.
+func TestSeek(t *testing.T) {
+	for _, b := range []ByteBuf{bb("hh0123456789"), splitComposite([]byte("hh0123456789"), 3, 6)} {
+		b.Skip(2)
+		s := b.(io.Seeker)
+		p := make([]byte, 1)
+		_, err := b.(io.ReaderAt).ReadAt(p, 0)
+		assert.NoError(t, err)
+		pos, err := s.Seek(0, io.SeekStart)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), pos)
+		assert.Equal(t, p[0], b.MustReadByte())
+
+		pos, err = s.Seek(3, io.SeekStart)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), pos)
+		assert.Equal(t, 6, b.ReaderIndex())
+		assert.Equal(t, "456789", string(b.BytesCopy()))
+
+		pos, err = s.Seek(1, io.SeekCurrent)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), pos)
+		assert.Equal(t, "56789", string(b.BytesCopy()))
+
+		pos, err = s.Seek(-2, io.SeekEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), pos)
+		assert.Equal(t, "89", string(b.BytesCopy()))
+
+		pos, err = s.Seek(0, io.SeekEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), pos)
+		assert.Equal(t, 0, b.ReadableBytes())
+
+		_, err = s.Seek(1, io.SeekEnd)
+		assert.ErrorIs(t, err, ErrInvalidOffset)
+		_, err = s.Seek(-1, io.SeekStart)
+		assert.ErrorIs(t, err, ErrInvalidOffset)
+		_, err = s.Seek(-1, io.SeekCurrent)
+		assert.ErrorIs(t, err, ErrInvalidOffset)
+		_, err = s.Seek(0, 7)
+		assert.ErrorIs(t, err, ErrInvalidWhence)
+		assert.Equal(t, 12, b.ReaderIndex())
+	}
+
+	// Compaction does not move the region Seek works on.
+	for _, b := range []ByteBuf{bb("hh0123"), splitComposite([]byte("hh0123"), 3)} {
+		s := b.(io.Seeker)
+		b.Skip(3).Compact()
+		pos, err := s.Seek(-1, io.SeekEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), pos)
+		assert.Equal(t, "3", string(b.BytesCopy()))
+	}
+}
+
+// Unreading below a reader mark and seeking keep the mark, and growth
+// afterwards compacts from the lower of the two.
+func TestSeek_MarkAndGrow(t *testing.T) {
+	for _, b := range []ByteBuf{EmptyByteBuf(), NewCompositeByteBuf()} {
+		b.WriteString("0123456789abcdef").Skip(14).MarkReaderIndex()
+		assert.NoError(t, b.(io.ByteScanner).UnreadByte())
+		assert.NoError(t, b.(io.ByteScanner).UnreadByte())
+		_, err := b.(io.Seeker).Seek(1, io.SeekCurrent)
+		assert.NoError(t, err)
+		b.WriteBytes(make([]byte, 64))
+		assert.Equal(t, "def", string(b.ReadBytes(3)))
+		b.ResetReaderIndex()
+		assert.Equal(t, "ef", string(b.ReadBytes(2)))
+	}
+}
+
+func TestUnreadByte(t *testing.T) {
+	for _, b := range []ByteBuf{bb("ab"), splitComposite([]byte("ab"), 1)} {
+		bs := b.(io.ByteScanner)
+		assert.ErrorIs(t, bs.UnreadByte(), ErrInvalidUnread)
+		b.MustReadByte()
+		c, err := bs.ReadByte()
+		assert.NoError(t, err)
+		assert.Equal(t, byte('b'), c)
+		assert.NoError(t, bs.UnreadByte())
+		assert.NoError(t, bs.UnreadByte())
+		assert.Equal(t, 0, b.ReaderIndex())
+		assert.Equal(t, "ab", string(b.BytesCopy()))
+	}
+}
+
+func TestReadRune_AcrossComponents(t *testing.T) {
+	s := []byte("aé😀z")
+	// Cut inside "é" (bytes 1..2) and inside "😀" (bytes 3..6).
+	for _, b := range []ByteBuf{bbBytes(s), splitComposite(s, 2, 4, 5)} {
+		rs := b.(io.RuneScanner)
+		var got []rune
+		var sizes []int
+		for {
+			r, size, err := rs.ReadRune()
+			if err == io.EOF {
+				assert.Equal(t, 0, size)
+				break
+			}
+			assert.NoError(t, err)
+			got = append(got, r)
+			sizes = append(sizes, size)
+		}
+		assert.Equal(t, []rune("aé😀z"), got)
+		assert.Equal(t, []int{1, 2, 4, 1}, sizes)
+	}
+}
+
+func TestReadRune_Invalid(t *testing.T) {
+	s := []byte{0xe2, 0x82, 'x', 0xff}
+	for _, b := range []ByteBuf{bbBytes(s), splitComposite(s, 1)} {
+		rs := b.(io.RuneScanner)
+		for _, want := range []rune{utf8.RuneError, utf8.RuneError, 'x', utf8.RuneError} {
+			r, size, err := rs.ReadRune()
+			assert.NoError(t, err)
+			assert.Equal(t, want, r)
+			assert.Equal(t, 1, size)
+		}
+	}
+}
+
+func TestUnreadRune(t *testing.T) {
+	s := []byte("é😀!")
+	for _, b := range []ByteBuf{bbBytes(s), splitComposite(s, 1, 4)} {
+		rs := b.(io.RuneScanner)
+		assert.ErrorIs(t, rs.UnreadRune(), ErrInvalidUnread)
+
+		rs.ReadRune()
+		r, size, _ := rs.ReadRune()
+		assert.Equal(t, '😀', r)
+		assert.Equal(t, 4, size)
+		assert.NoError(t, rs.UnreadRune())
+		assert.Equal(t, 2, b.ReaderIndex())
+		// Only one rune can be unread.
+		assert.ErrorIs(t, rs.UnreadRune(), ErrInvalidUnread)
+
+		r, _, _ = rs.ReadRune()
+		assert.Equal(t, '😀', r)
+		b.MustReadByte()
+		// A byte read after ReadRune invalidates UnreadRune.
+		assert.ErrorIs(t, rs.UnreadRune(), ErrInvalidUnread)
+
+		b.ResetReaderIndex()
+		rs.ReadRune()
+		assert.NoError(t, rs.UnreadRune())
+		assert.Equal(t, 0, b.ReaderIndex())
+	}
+}
+
+func TestReadRune_RegexpMatchReader(t *testing.T) {
+	re := regexp.MustCompile(`ü+ber`)
+	c := splitComposite([]byte("xxüüber"), 3, 5)
+	assert.True(t, re.MatchReader(c.(io.RuneReader)))
+	assert.False(t, re.MatchReader(splitComposite([]byte("uber"), 1).(io.RuneReader)))
+}
+
+func TestOrder_ReaderAtSeekerScanner(t *testing.T) {
+	o := Order(splitComposite([]byte("\x01\x00é"), 1, 3), binary.LittleEndian)
+	p := make([]byte, 2)
+	n, err := o.(io.ReaderAt).ReadAt(p, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, uint16(1), o.ReadUInt16())
+	rs := o.(io.RuneScanner)
+	r, _, err := rs.ReadRune()
+	assert.NoError(t, err)
+	assert.Equal(t, 'é', r)
+	assert.NoError(t, rs.UnreadRune())
+	assert.NoError(t, o.(io.ByteScanner).UnreadByte())
+	pos, err := o.(io.Seeker).Seek(0, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), pos)
+	assert.Equal(t, 0, o.ReadableBytes())
+
+	_, ok := Order(struct{ ByteBuf }{bb("x")}, nil).(io.Seeker)
+	assert.False(t, ok)
+}
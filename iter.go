@@ -0,0 +1,86 @@
+package buf
+
+import (
+	"errors"
+	"iter"
+)
+
+// ErrEmptySeparator is raised by Split for an empty separator.
+var ErrEmptySeparator = errors.New("empty separator")
+
+// Segments returns an iterator over the readable region of bb as
+// contiguous byte slices: one per component for a composite and a single
+// slice for a DefaultByteBuf. The slices alias bb's storage; indices are not
+// moved and composites are not consolidated. bb must not be modified while
+// the iteration runs.
+func Segments(bb ByteBuf) iter.Seq[[]byte] {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	return func(yield func([]byte) bool) {
+		cur := newSegmentCursor(bb, bb.ReaderIndex(), bb.ReadableBytes())
+		for _, seg := cur.next(); seg != nil; _, seg = cur.next() {
+			if !yield(seg) {
+				return
+			}
+		}
+	}
+}
+
+// Split returns an iterator over the tokens of bb's readable region
+// separated by sep, following bytes.Split: n separators yield n+1 tokens,
+// including empty ones at either end. Each token is a zero-copy Slice of
+// bb when it implements Slicer and a copy otherwise. Indices are not
+// moved; bb must not be modified while the iteration runs. It panics with
+// ErrEmptySeparator when sep is empty.
+func Split(bb ByteBuf, sep []byte) iter.Seq[ByteBuf] {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	if len(sep) == 0 {
+		panic(ErrEmptySeparator)
+	}
+	return func(yield func(ByteBuf) bool) {
+		n := bb.ReadableBytes()
+		for from := 0; ; {
+			i := indexOf(bb, from, sep)
+			end := i
+			if i < 0 {
+				end = n
+			}
+			if !yield(sliceOf(bb, from, end-from)) || i < 0 {
+				return
+			}
+			from = i + len(sep)
+		}
+	}
+}
+
+// sliceOf returns [from, from+length) of bb's readable region, as a view
+// when bb supports one.
+func sliceOf(bb ByteBuf, from, length int) ByteBuf {
+	if s, ok := bb.(Slicer); ok {
+		return s.Slice(from, length)
+	}
+	return NewSharedByteBuf(bb.BytesCopy()[from : from+length])
+}
+
+// ForEachByte calls fn for each readable byte of bb in order until fn
+// returns false, and returns the absolute index of that byte, or -1 when
+// fn accepted every byte. It is the counterpart of Netty's forEachByte with
+// a ByteProcessor. Indices are not moved and composites are walked
+// component by component.
+func ForEachByte(bb ByteBuf, fn func(byte) bool) int {
+	if bb == nil || fn == nil {
+		panic(ErrNilObject)
+	}
+	cur := newSegmentCursor(bb, bb.ReaderIndex(), bb.ReadableBytes())
+	for start, seg := cur.next(); seg != nil; start, seg = cur.next() {
+		for i, c := range seg {
+			if !fn(c) {
+				return start + i
+			}
+		}
+	}
+	return -1
+}
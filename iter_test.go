@@ -0,0 +1,95 @@
+package buf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegments(t *testing.T) {
+	c := NewCompositeByteBuf(bb("abc"), bb("de"), bb("fgh"))
+	c.Skip(1)
+	var segs []string
+	for seg := range Segments(c) {
+		segs = append(segs, string(seg))
+	}
+	assert.Equal(t, []string{"bc", "de", "fgh"}, segs)
+	assert.Len(t, asDefault(c).components, 3)
+	assert.Equal(t, 1, c.ReaderIndex())
+
+	segs = segs[:0]
+	for seg := range Segments(bb("flat")) {
+		segs = append(segs, string(seg))
+	}
+	assert.Equal(t, []string{"flat"}, segs)
+
+	for range Segments(EmptyByteBuf()) {
+		t.Fatal("empty buffer yielded a segment")
+	}
+
+	// Breaking out early stops the walk.
+	count := 0
+	for range Segments(c) {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestSplit(t *testing.T) {
+	inputs := []string{"a,b,,c", ",a,", "", "abc", "a,,"}
+	for _, in := range inputs {
+		want := bytes.Split([]byte(in), []byte(","))
+		for _, b := range []ByteBuf{bb(in), splitComposite([]byte(in), len(in)/2)} {
+			var got [][]byte
+			for tok := range Split(b, []byte(",")) {
+				got = append(got, tok.BytesCopy())
+			}
+			assert.Equal(t, len(want), len(got), in)
+			for i := range want {
+				assert.Equal(t, string(want[i]), string(got[i]), in)
+			}
+		}
+	}
+}
+
+func TestSplit_ZeroCopyAcrossComponents(t *testing.T) {
+	src := []byte("key1\r\nvalue\r\nkey2")
+	c := splitComposite(src, 5, 9)
+	var toks []ByteBuf
+	for tok := range Split(c, []byte("\r\n")) {
+		toks = append(toks, tok)
+	}
+	assert.Len(t, toks, 3)
+	assert.Equal(t, "key1", string(toks[0].BytesCopy()))
+	assert.Equal(t, "value", string(toks[1].BytesCopy()))
+	assert.Equal(t, "key2", string(toks[2].BytesCopy()))
+
+	// Tokens share storage with the source.
+	src[6] = 'V'
+	assert.Equal(t, "Value", string(toks[1].BytesCopy()))
+	assert.Equal(t, 0, c.ReaderIndex())
+
+	assert.PanicsWithValue(t, ErrEmptySeparator, func() { Split(c, nil) })
+}
+
+func TestForEachByte(t *testing.T) {
+	c := NewCompositeByteBuf(bb("xxab"), bb("c\nd"))
+	c.Skip(2)
+	idx := ForEachByte(c, func(b byte) bool { return b != '\n' })
+	assert.Equal(t, 5, idx)
+	assert.Equal(t, byte('\n'), c.(AbsoluteAccessor).GetByte(idx))
+	assert.Equal(t, 2, c.ReaderIndex())
+
+	var seen []byte
+	assert.Equal(t, -1, ForEachByte(c, func(b byte) bool {
+		seen = append(seen, b)
+		return true
+	}))
+	assert.Equal(t, "abc\nd", string(seen))
+
+	b := bb("hello")
+	b.Skip(1)
+	assert.Equal(t, 2, ForEachByte(b, func(c byte) bool { return c != 'l' }))
+}
@@ -0,0 +1,337 @@
+package buf
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LeakDetectionLevel selects which buffers the leak detector tracks.
+type LeakDetectionLevel int32
+
+const (
+	// LeakDetectionDisabled tracks nothing. It is the default.
+	LeakDetectionDisabled LeakDetectionLevel = iota
+	// LeakDetectionSampled tracks one in SamplingInterval acquired buffers,
+	// cheap enough to leave on in production.
+	LeakDetectionSampled
+	// LeakDetectionParanoid tracks every acquired buffer, for tests and
+	// debugging.
+	LeakDetectionParanoid
+)
+
+// LeakDetectionConfig configures the leak detector. It covers the buffers
+// that must be released: those returned by AcquireByteBuf, and so by the
+// Pooled allocator, and unpooled buffers passed to Managed. A tracked
+// buffer leaks when it is garbage-collected before it was passed to
+// ReleaseByteBuf or released down to refcount zero. Buffers from
+// NewByteBuf and its variants and composites are not tracked, since the
+// garbage collector reclaims them without a release; the pooled components
+// of a composite are tracked on their own.
+type LeakDetectionConfig struct {
+	Level LeakDetectionLevel
+	// SamplingInterval is the mean number of acquires per tracked buffer
+	// at LeakDetectionSampled. Zero means 128.
+	SamplingInterval int
+	// Records is how many of the most recent Retain, Release and Touch
+	// calls are kept per tracked buffer, with their call sites. Zero keeps
+	// none; only the acquire site is reported then.
+	Records int
+	// Reporter receives the leaks found. Nil logs them with slog.Default.
+	Reporter LeakReporter
+}
+
+// LeakReporter receives leak reports. ReportLeak is called from the
+// runtime's cleanup goroutine, so it must not block for long, and a panic
+// in it terminates the process.
+type LeakReporter interface {
+	ReportLeak(r LeakReport)
+}
+
+// LeakReporterFunc adapts a function to LeakReporter.
+type LeakReporterFunc func(r LeakReport)
+
+func (f LeakReporterFunc) ReportLeak(r LeakReport) { f(r) }
+
+// LeakReport describes a buffer that was garbage-collected while still
+// referenced.
+type LeakReport struct {
+	// Cap is the capacity the buffer was acquired with.
+	Cap int
+	// Acquired is the stack of the AcquireByteBuf or Managed call.
+	Acquired string
+	// Records holds the most recent Retain, Release and Touch calls,
+	// oldest first.
+	Records []LeakRecord
+	// DroppedRecords counts older calls that no longer fit in Records.
+	DroppedRecords int
+}
+
+// LeakRecord is one recorded access to a tracked buffer.
+type LeakRecord struct {
+	// Op is "retain", "release" or "touch".
+	Op string
+	// Hint is the hint passed to Touch.
+	Hint string
+	// RefCnt is the reference count after the call.
+	RefCnt int32
+	// Stack is the call site.
+	Stack string
+}
+
+func (r LeakReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "LEAK: ByteBuf (cap %d) garbage-collected before release\nacquired at:\n%s", r.Cap, r.Acquired)
+	for i := len(r.Records) - 1; i >= 0; i-- {
+		rec := r.Records[i]
+		fmt.Fprintf(&sb, "#%d %s refcnt=%d", len(r.Records)-i, rec.Op, rec.RefCnt)
+		if rec.Hint != "" {
+			fmt.Fprintf(&sb, " hint=%q", rec.Hint)
+		}
+		fmt.Fprintf(&sb, ":\n%s", rec.Stack)
+	}
+	if r.DroppedRecords > 0 {
+		fmt.Fprintf(&sb, "%d older records dropped\n", r.DroppedRecords)
+	}
+	return sb.String()
+}
+
+// SlogLeakReporter logs each leak at error level on l; nil means
+// slog.Default.
+func SlogLeakReporter(l *slog.Logger) LeakReporter {
+	return LeakReporterFunc(func(r LeakReport) {
+		logger := l
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.LogAttrs(context.Background(), slog.LevelError, "ByteBuf leak",
+			slog.Int("cap", r.Cap), slog.String("report", r.String()))
+	})
+}
+
+// LeakRecorder is a LeakReporter that keeps every report, so a test can
+// force a garbage collection and then assert that nothing leaked.
+type LeakRecorder struct {
+	mu      sync.Mutex
+	reports []LeakReport
+}
+
+func (r *LeakRecorder) ReportLeak(l LeakReport) {
+	r.mu.Lock()
+	r.reports = append(r.reports, l)
+	r.mu.Unlock()
+}
+
+// Leaks returns the reports received so far, oldest first.
+func (r *LeakRecorder) Leaks() []LeakReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]LeakReport(nil), r.reports...)
+}
+
+// LeakTB is the part of testing.TB that TestingLeakReporter uses.
+type LeakTB interface {
+	Helper()
+	Cleanup(f func())
+	Errorf(format string, args ...any)
+}
+
+// TestingLeakReporter returns a LeakReporter that fails t on leaks. It
+// records reports like LeakRecorder, and when t finishes it collects
+// garbage, waits for the pending cleanups and reports every leak found so
+// far with t.Errorf; a leak whose cleanup runs later still is missed. This is the way to make leaks fail a test: a
+// reporter that panics would take down the whole test binary from the
+// cleanup goroutine instead.
+//
+// The detector configuration is global, so leaks of buffers acquired by
+// other tests running at the same time are reported to t as well.
+func TestingLeakReporter(t LeakTB) LeakReporter {
+	t.Helper()
+	rec := &LeakRecorder{}
+	t.Cleanup(func() {
+		t.Helper()
+		awaitCleanups()
+		for _, l := range rec.Leaks() {
+			t.Errorf("%s", l)
+		}
+	})
+	return rec
+}
+
+// awaitCleanups runs two garbage collections and after each waits, for
+// up to a second, until the cleanup of a sentinel collected with it has
+// run, by which time the leak reports queued alongside have normally run
+// too. The sentinel is 16 bytes so the tiny allocator does not batch it.
+func awaitCleanups() {
+	for range 2 {
+		done := make(chan struct{})
+		runtime.AddCleanup(new([16]byte), func(ch chan struct{}) { close(ch) }, done)
+		runtime.GC()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// LeakCounter is a LeakReporter that only counts leaks, e.g. for a metric.
+type LeakCounter struct {
+	n atomic.Int64
+}
+
+func (c *LeakCounter) ReportLeak(LeakReport) { c.n.Add(1) }
+
+// Count returns the number of leaks reported so far.
+func (c *LeakCounter) Count() int64 { return c.n.Load() }
+
+var leakConfig atomic.Pointer[LeakDetectionConfig]
+
+// SetLeakDetection replaces the leak detector configuration. Buffers
+// already tracked keep being tracked under the settings they were
+// acquired with.
+func SetLeakDetection(cfg LeakDetectionConfig) {
+	if cfg.SamplingInterval <= 0 {
+		cfg.SamplingInterval = 128
+	}
+	if cfg.Records < 0 {
+		cfg.Records = 0
+	}
+	if cfg.Reporter == nil {
+		cfg.Reporter = SlogLeakReporter(nil)
+	}
+	leakConfig.Store(&cfg)
+}
+
+// LeakDetection returns the current leak detector configuration.
+func LeakDetection() LeakDetectionConfig {
+	if cfg := leakConfig.Load(); cfg != nil {
+		return *cfg
+	}
+	return LeakDetectionConfig{}
+}
+
+// Touch records hint as an access to bb when bb is tracked by the leak
+// detector, so a leak report shows where the buffer last went. It returns
+// bb.
+func Touch(bb ByteBuf, hint string) ByteBuf {
+	inner := bb
+	if o, ok := bb.(OrderedByteBuf); ok {
+		inner = o.Unwrap()
+	}
+	if b, ok := inner.(*DefaultByteBuf); ok {
+		if t := b.leakOf(); t != nil {
+			t.record("touch", hint, b.refcnt.Load())
+		}
+	}
+	return bb
+}
+
+// leakStackDepth bounds the frames captured per stack.
+const leakStackDepth = 32
+
+// leakTracker follows one acquired buffer. The runtime cleanup attached to
+// the buffer holds the tracker, never the buffer, so the buffer can still
+// be collected.
+type leakTracker struct {
+	cfg      *LeakDetectionConfig
+	cap      int
+	acquired []uintptr
+	cleanup  runtime.Cleanup
+	closed   atomic.Bool
+
+	mu      sync.Mutex
+	records []leakAccess // ring of the last cfg.Records accesses
+	total   int
+}
+
+type leakAccess struct {
+	op, hint string
+	refcnt   int32
+	stack    []uintptr
+}
+
+// trackLeak starts tracking b when the detector is on and b is sampled.
+// The recorded stack starts skip frames above the caller of trackLeak, so
+// each exported entry point passes the depth at which its own caller sits.
+func trackLeak(b *DefaultByteBuf, skip int) {
+	cfg := leakConfig.Load()
+	if cfg == nil || cfg.Level == LeakDetectionDisabled {
+		return
+	}
+	if cfg.Level == LeakDetectionSampled && rand.IntN(cfg.SamplingInterval) != 0 {
+		return
+	}
+	t := &leakTracker{cfg: cfg, cap: b.Cap(), acquired: callers(2 + skip)}
+	t.cleanup = runtime.AddCleanup(b, (*leakTracker).report, t)
+	b.sideOf().leak.Store(t)
+}
+
+// closeLeak ends tracking of b because it was released properly.
+func closeLeak(b *DefaultByteBuf) {
+	sd := b.side.Load()
+	if sd == nil {
+		return
+	}
+	if t := sd.leak.Swap(nil); t != nil {
+		t.closed.Store(true)
+		t.cleanup.Stop()
+	}
+}
+
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, leakStackDepth)
+	return pcs[:runtime.Callers(skip+1, pcs)]
+}
+
+func (t *leakTracker) record(op, hint string, refcnt int32) {
+	n := t.cfg.Records
+	if n == 0 {
+		return
+	}
+	a := leakAccess{op: op, hint: hint, refcnt: refcnt, stack: callers(3)}
+	t.mu.Lock()
+	if len(t.records) < n {
+		t.records = append(t.records, a)
+	} else {
+		t.records[t.total%n] = a
+	}
+	t.total++
+	t.mu.Unlock()
+}
+
+func (t *leakTracker) report() {
+	if t.closed.Load() {
+		return
+	}
+	r := LeakReport{Cap: t.cap, Acquired: formatStack(t.acquired)}
+	t.mu.Lock()
+	n := len(t.records)
+	for i := range n {
+		a := t.records[(t.total-n+i)%n]
+		r.Records = append(r.Records, LeakRecord{Op: a.op, Hint: a.hint, RefCnt: a.refcnt, Stack: formatStack(a.stack)})
+	}
+	r.DroppedRecords = t.total - n
+	t.mu.Unlock()
+	t.cfg.Reporter.ReportLeak(r)
+}
+
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		fmt.Fprintf(&sb, "\t%s\n\t\t%s:%d\n", f.Function, f.File, f.Line)
+		if !more {
+			return sb.String()
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package buf
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withLeakDetection applies cfg until the end of the test.
+func withLeakDetection(t *testing.T, cfg LeakDetectionConfig) {
+	prev := LeakDetection()
+	SetLeakDetection(cfg)
+	t.Cleanup(func() { SetLeakDetection(prev) })
+}
+
+// awaitLeak runs GCs until a report arrives or a second passes.
+func awaitLeak(reports <-chan LeakReport) (LeakReport, bool) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case r := <-reports:
+			return r, true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return LeakReport{}, false
+}
+
+//go:noinline
+func leakBuffer() {
+	b := AcquireByteBuf(100)
+	b.WriteString("leak")
+	b.(RefCounted).Retain()
+	Touch(b, "handed to the writer")
+	b.(RefCounted).Release()
+}
+
+func TestLeakDetection_ReportsLeak(t *testing.T) {
+	reports := make(chan LeakReport, 16)
+	withLeakDetection(t, LeakDetectionConfig{
+		Level:    LeakDetectionParanoid,
+		Records:  2,
+		Reporter: LeakReporterFunc(func(r LeakReport) { reports <- r }),
+	})
+
+	leakBuffer()
+	r, ok := awaitLeak(reports)
+	if !assert.True(t, ok, "no leak reported") {
+		return
+	}
+	assert.Equal(t, 256, r.Cap)
+	assert.Contains(t, r.Acquired, "leakBuffer")
+	assert.Len(t, r.Records, 2)
+	assert.Equal(t, 1, r.DroppedRecords)
+	assert.Equal(t, "touch", r.Records[0].Op)
+	assert.Equal(t, "handed to the writer", r.Records[0].Hint)
+	assert.Equal(t, int32(2), r.Records[0].RefCnt)
+	assert.Equal(t, "release", r.Records[1].Op)
+	assert.Contains(t, r.Records[1].Stack, "leakBuffer")
+	assert.True(t, strings.HasPrefix(r.String(), "LEAK: ByteBuf (cap 256)"))
+	assert.Contains(t, r.String(), `hint="handed to the writer"`)
+}
+
+func TestLeakDetection_ReleasedIsNotReported(t *testing.T) {
+	var counter LeakCounter
+	withLeakDetection(t, LeakDetectionConfig{Level: LeakDetectionParanoid, Reporter: &counter})
+
+	func() {
+		ReleaseByteBuf(AcquireByteBuf(1 << 20))
+		b := AcquireByteBuf(64)
+		ReleaseByteBuf(b)
+		rc := AcquireByteBuf(64).(RefCounted)
+		rc.Retain()
+		rc.Release()
+		assert.True(t, rc.Release())
+	}()
+	for range 3 {
+		runtime.GC()
+	}
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, counter.Count())
+}
+
+//go:noinline
+func leakManaged() {
+	b := Managed(NewByteBufString("managed").(*DefaultByteBuf))
+	b.Retain()
+	b.Release()
+}
+
+// Managed unpooled buffers are tracked; plain unpooled ones are not.
+func TestLeakDetection_Managed(t *testing.T) {
+	reports := make(chan LeakReport, 16)
+	withLeakDetection(t, LeakDetectionConfig{
+		Level:    LeakDetectionParanoid,
+		Reporter: LeakReporterFunc(func(r LeakReport) { reports <- r }),
+	})
+
+	b := NewByteBufString("plain").(*DefaultByteBuf)
+	assert.Nil(t, b.leakOf())
+	m := Managed(NewByteBufString("released").(*DefaultByteBuf))
+	assert.NotNil(t, m.leakOf())
+	assert.True(t, m.Release())
+	assert.Nil(t, m.leakOf())
+
+	leakManaged()
+	r, ok := awaitLeak(reports)
+	if !assert.True(t, ok, "no leak reported") {
+		return
+	}
+	assert.Contains(t, r.Acquired, "leakManaged")
+}
+
+// The acquire stack starts at the code calling each entry point.
+func TestLeakDetection_AcquireSite(t *testing.T) {
+	withLeakDetection(t, LeakDetectionConfig{Level: LeakDetectionParanoid})
+	for _, b := range []*DefaultByteBuf{
+		AcquireByteBuf(64).(*DefaultByteBuf),
+		AcquireByteBuf(1 << 20).(*DefaultByteBuf),
+		AcquireManagedByteBuf(64),
+		Pooled.Buffer(64).(*DefaultByteBuf),
+		Managed(NewByteBufString("x").(*DefaultByteBuf)),
+	} {
+		frame, _ := runtime.CallersFrames(b.leakOf().acquired).Next()
+		assert.True(t, strings.HasSuffix(frame.Function, ".TestLeakDetection_AcquireSite"), frame.Function)
+		ReleaseByteBuf(b)
+	}
+}
+
+// LeakRecorder keeps the reports for a later assertion.
+func TestLeakDetection_Recorder(t *testing.T) {
+	rec := &LeakRecorder{}
+	withLeakDetection(t, LeakDetectionConfig{Level: LeakDetectionParanoid, Reporter: rec})
+
+	leakManaged()
+	deadline := time.Now().Add(time.Second)
+	for len(rec.Leaks()) == 0 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if assert.Len(t, rec.Leaks(), 1) {
+		assert.Contains(t, rec.Leaks()[0].Acquired, "leakManaged")
+	}
+}
+
+// fakeTB collects the cleanups and errors of a TestingLeakReporter.
+type fakeTB struct {
+	cleanups []func()
+	errors   []string
+}
+
+func (f *fakeTB) Helper()           {}
+func (f *fakeTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+// TestingLeakReporter fails the test at its end with each leak found.
+func TestLeakDetection_TestingReporter(t *testing.T) {
+	tb := &fakeTB{}
+	withLeakDetection(t, LeakDetectionConfig{Level: LeakDetectionParanoid, Reporter: TestingLeakReporter(tb)})
+	leakManaged()
+	b := AcquireByteBuf(64)
+	ReleaseByteBuf(b)
+	assert.Empty(t, tb.errors)
+	if assert.Len(t, tb.cleanups, 1) {
+		tb.cleanups[0]()
+	}
+	if assert.Len(t, tb.errors, 1) {
+		assert.Contains(t, tb.errors[0], "leakManaged")
+	}
+
+	clean := &fakeTB{}
+	TestingLeakReporter(clean)
+	clean.cleanups[0]()
+	assert.Empty(t, clean.errors)
+}
+
+func TestLeakDetection_Levels(t *testing.T) {
+	withLeakDetection(t, LeakDetectionConfig{})
+	b := AcquireByteBuf(64).(*DefaultByteBuf)
+	assert.Nil(t, b.leakOf())
+	ReleaseByteBuf(b)
+
+	withLeakDetection(t, LeakDetectionConfig{Level: LeakDetectionSampled, SamplingInterval: 1})
+	b = AcquireByteBuf(64).(*DefaultByteBuf)
+	assert.NotNil(t, b.leakOf())
+	ReleaseByteBuf(b)
+	assert.Nil(t, b.leakOf())
+
+	withLeakDetection(t, LeakDetectionConfig{Level: LeakDetectionSampled})
+	assert.Equal(t, 128, LeakDetection().SamplingInterval)
+	assert.NotNil(t, LeakDetection().Reporter)
+}
@@ -0,0 +1,255 @@
+package buf
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// LengthFieldVarint selects an unsigned LEB128 varint length field instead
+// of a fixed-width one.
+const LengthFieldVarint = -1
+
+// LengthFieldConfig describes the framing handled by LengthFieldFrameDecoder.
+//
+// The frame length is computed as
+//
+//	LengthFieldOffset + width(length field) + value(length field) + LengthAdjustment
+//
+// and InitialBytesToStrip bytes are dropped from the front of every frame
+// before it is handed out.
+type LengthFieldConfig struct {
+	// MaxFrameLength bounds the total frame length, including the header
+	// bytes ahead of and inside the length field. Must be positive.
+	MaxFrameLength int
+	// LengthFieldOffset is the number of bytes preceding the length field.
+	LengthFieldOffset int
+	// LengthFieldLength is the width of the length field in bytes: 1, 2, 3,
+	// 4, 8, or LengthFieldVarint.
+	LengthFieldLength int
+	// ByteOrder of a fixed-width length field. Nil means big-endian.
+	ByteOrder binary.ByteOrder
+	// LengthAdjustment is added to the length field value, e.g. a negative
+	// header size when the field counts the whole frame.
+	LengthAdjustment int
+	// InitialBytesToStrip is removed from the front of each decoded frame.
+	InitialBytesToStrip int
+	// Allocator supplies the cumulation. Nil allocates it directly.
+	Allocator Allocator
+}
+
+// LengthFieldFrameDecoder cuts frames out of a byte stream whose frames
+// carry a length prefix. Incoming ByteBufs are cumulated into a
+// CompositeByteBuf without copying, and every decoded frame is a ReadSlice
+// view over the cumulation.
+//
+// Added buffers are aliased, so callers must not overwrite them while any
+// frame derived from them is still in use. Close hands the cumulation back
+// to the configured allocator.
+//
+// LengthFieldFrameDecoder is NOT goroutine-safe.
+type LengthFieldFrameDecoder struct {
+	cfg            LengthFieldConfig
+	littleEndian   bool
+	cumulation     CompositeByteBuf
+	bytesToDiscard int
+	// corrupted is set by a length field no int can hold; the stream has
+	// lost its framing and only Reset recovers.
+	corrupted bool
+}
+
+// NewLengthFieldFrameDecoder returns a decoder for cfg. It panics with
+// ErrInvalidFrameConfig when cfg is not usable.
+func NewLengthFieldFrameDecoder(cfg LengthFieldConfig) *LengthFieldFrameDecoder {
+	switch cfg.LengthFieldLength {
+	case 1, 2, 3, 4, 8, LengthFieldVarint:
+	default:
+		panic(ErrInvalidFrameConfig)
+	}
+	if cfg.MaxFrameLength <= 0 || cfg.LengthFieldOffset < 0 || cfg.InitialBytesToStrip < 0 {
+		panic(ErrInvalidFrameConfig)
+	}
+	if cfg.ByteOrder == nil {
+		cfg.ByteOrder = binary.BigEndian
+	}
+	return &LengthFieldFrameDecoder{
+		cfg:          cfg,
+		littleEndian: isLittleEndian(cfg.ByteOrder),
+		cumulation:   compositeFrom(cfg.Allocator),
+	}
+}
+
+// Add appends the readable region of in to the cumulation and marks it as
+// consumed on in. A corrupted decoder drops in instead.
+func (d *LengthFieldFrameDecoder) Add(in ByteBuf) {
+	if d.corrupted {
+		if in == nil {
+			panic(ErrNilObject)
+		}
+		in.Skip(in.ReadableBytes())
+		return
+	}
+	cumulate(d.cumulation, in)
+	d.discard()
+}
+
+// Reset drops the cumulated bytes and any frame being discarded, and makes
+// a corrupted decoder usable again. Frames already handed out must not be
+// used afterwards.
+func (d *LengthFieldFrameDecoder) Reset() {
+	d.cumulation.Reset()
+	d.bytesToDiscard = 0
+	d.corrupted = false
+}
+
+// Close releases the cumulation to the configured allocator. Neither the
+// decoder nor the frames it handed out may be used afterwards.
+func (d *LengthFieldFrameDecoder) Close() error {
+	releaseComposite(d.cfg.Allocator, d.cumulation)
+	d.bytesToDiscard = 0
+	return nil
+}
+
+// Buffered returns the number of cumulated bytes not yet handed out.
+func (d *LengthFieldFrameDecoder) Buffered() int {
+	return d.cumulation.ReadableBytes()
+}
+
+// Decode adds in and returns every frame that is now complete. A non-nil
+// error is returned together with the frames decoded before it.
+func (d *LengthFieldFrameDecoder) Decode(in ByteBuf) ([]ByteBuf, error) {
+	d.Add(in)
+	return drainFrames(d.Next)
+}
+
+// Next returns the next complete frame, or nil when more input is needed.
+// ErrFrameTooLong is returned once per oversized frame, whose bytes are then
+// skipped as they arrive. ErrCorruptedFrame skips past the length field,
+// or past the frame when it is shorter than InitialBytesToStrip, except for a frame length beyond int range: the decoder cannot tell where
+// such a frame ends, so it drops the cumulation and keeps returning
+// ErrCorruptedFrame until Reset.
+func (d *LengthFieldFrameDecoder) Next() (ByteBuf, error) {
+	if d.corrupted {
+		return nil, ErrCorruptedFrame
+	}
+	defer d.cumulation.Compact()
+	if d.bytesToDiscard > 0 {
+		return nil, nil
+	}
+	c := d.cumulation
+	fieldEnd, value, ok, err := d.readLengthField()
+	if err != nil {
+		c.Skip(min(fieldEnd, c.ReadableBytes()))
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+	frameLen, ok, overflow := d.frameLength(fieldEnd, value)
+	if overflow {
+		c.Skip(c.ReadableBytes())
+		d.corrupted = true
+		return nil, ErrCorruptedFrame
+	}
+	if !ok {
+		c.Skip(fieldEnd)
+		return nil, ErrCorruptedFrame
+	}
+	if frameLen > d.cfg.MaxFrameLength {
+		d.startDiscard(frameLen)
+		return nil, ErrFrameTooLong
+	}
+	if d.cfg.InitialBytesToStrip > frameLen {
+		d.startDiscard(frameLen)
+		return nil, ErrCorruptedFrame
+	}
+	if c.ReadableBytes() < frameLen {
+		return nil, nil
+	}
+	c.Skip(d.cfg.InitialBytesToStrip)
+	return c.ReadSlice(frameLen - d.cfg.InitialBytesToStrip), nil
+}
+
+// frameLength returns fieldEnd + value + LengthAdjustment. ok is false when
+// the frame would end inside its header, and overflow is set when the sum
+// does not fit in an int.
+func (d *LengthFieldFrameDecoder) frameLength(fieldEnd int, value uint64) (frameLen int, ok, overflow bool) {
+	if adj := d.cfg.LengthAdjustment; adj < 0 {
+		drop := uint64(-(adj + 1)) + 1
+		if value < drop {
+			return 0, false, false
+		}
+		value -= drop
+	} else if value += uint64(adj); value < uint64(adj) {
+		return 0, false, true
+	}
+	if value > uint64(math.MaxInt-fieldEnd) {
+		return 0, false, true
+	}
+	return fieldEnd + int(value), true, false
+}
+
+// readLengthField peeks the length field at LengthFieldOffset from the
+// reader index. ok is false while the field is still incomplete. fieldEnd
+// is the offset just past the length field.
+func (d *LengthFieldFrameDecoder) readLengthField() (fieldEnd int, value uint64, ok bool, err error) {
+	c := d.cumulation
+	pos := c.ReaderIndex() + d.cfg.LengthFieldOffset
+	avail := c.WriterIndex() - pos
+	width := d.cfg.LengthFieldLength
+	if width == LengthFieldVarint {
+		var tmp [maxVarintLen64]byte
+		if avail <= 0 {
+			return 0, 0, false, nil
+		}
+		n := min(avail, len(tmp))
+		getBytes(c, pos, tmp[:n])
+		v, used, verr := decodeVarUInt(tmp[:n], maxVarintLen64, 0x01)
+		if verr == ErrInsufficientSize {
+			return 0, 0, false, nil
+		}
+		if verr != nil {
+			return d.cfg.LengthFieldOffset + n, 0, false, ErrCorruptedFrame
+		}
+		return d.cfg.LengthFieldOffset + used, v, true, nil
+	}
+	if avail < width {
+		return 0, 0, false, nil
+	}
+	var tmp [8]byte
+	getBytes(c, pos, tmp[:width])
+	fieldEnd = d.cfg.LengthFieldOffset + width
+	switch width {
+	case 1:
+		value = uint64(tmp[0])
+	case 2:
+		value = uint64(d.cfg.ByteOrder.Uint16(tmp[:]))
+	case 3:
+		if d.littleEndian {
+			value = uint64(tmp[0]) | uint64(tmp[1])<<8 | uint64(tmp[2])<<16
+		} else {
+			value = uint64(tmp[0])<<16 | uint64(tmp[1])<<8 | uint64(tmp[2])
+		}
+	case 4:
+		value = uint64(d.cfg.ByteOrder.Uint32(tmp[:]))
+	case 8:
+		value = d.cfg.ByteOrder.Uint64(tmp[:])
+	}
+	return fieldEnd, value, true, nil
+}
+
+// startDiscard drops up to frameLen bytes now and remembers how many more
+// must be skipped as further input arrives.
+func (d *LengthFieldFrameDecoder) startDiscard(frameLen int) {
+	d.bytesToDiscard = frameLen
+	d.discard()
+}
+
+func (d *LengthFieldFrameDecoder) discard() {
+	if d.bytesToDiscard == 0 {
+		return
+	}
+	n := min(d.bytesToDiscard, d.cumulation.ReadableBytes())
+	d.cumulation.Skip(n)
+	d.bytesToDiscard -= n
+	d.cumulation.Compact()
+}
@@ -0,0 +1,196 @@
+package buf
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func frameStrings(frames []ByteBuf) []string {
+	out := make([]string, 0, len(frames))
+	for _, f := range frames {
+		out = append(out, string(f.BytesCopy()))
+	}
+	return out
+}
+
+// Frames split across several inputs come out once complete; the remainder
+// stays buffered.
+func TestLengthFieldDecoder_FragmentedInput(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:      1024,
+		LengthFieldLength:   2,
+		InitialBytesToStrip: 2,
+	})
+	frames, err := d.Decode(NewByteBuf([]byte{0x00, 0x05, 'h', 'e'}))
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+
+	frames, err = d.Decode(NewByteBuf([]byte{'l', 'l', 'o', 0x00, 0x02, 'o', 'k', 0x00}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hello", "ok"}, frameStrings(frames))
+	assert.Equal(t, 1, d.Buffered())
+}
+
+// Input buffers are consumed and frames alias them instead of copying.
+func TestLengthFieldDecoder_ZeroCopyAndCompact(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:    64,
+		LengthFieldLength: 1,
+	})
+	src := []byte{0x03, 'a', 'b', 'c'}
+	in := NewSharedByteBuf(src)
+	frames, err := d.Decode(in)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, in.ReadableBytes())
+	assert.Len(t, frames, 1)
+
+	src[1] = 'A'
+	assert.Equal(t, "Abc", string(frames[0].BytesCopy()[1:]))
+	assert.Equal(t, 0, d.cumulation.ReaderIndex())
+	assert.Equal(t, 0, len(asDefault(d.cumulation).components))
+}
+
+// Offset, byte order, 3-byte width and adjustment follow the Netty formula.
+func TestLengthFieldDecoder_OffsetAdjustmentLE(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:      64,
+		LengthFieldOffset:   1,
+		LengthFieldLength:   3,
+		ByteOrder:           binary.LittleEndian,
+		LengthAdjustment:    -4,
+		InitialBytesToStrip: 4,
+	})
+	// Length field counts the whole frame: 1 tag + 3 length + 2 body.
+	frames, err := d.Decode(NewByteBuf([]byte{0xAA, 0x06, 0x00, 0x00, 'h', 'i'}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"hi"}, frameStrings(frames))
+}
+
+// Varint length fields decode across component boundaries.
+func TestLengthFieldDecoder_Varint(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:      1024,
+		LengthFieldLength:   LengthFieldVarint,
+		InitialBytesToStrip: 2,
+	})
+	payload := make([]byte, 200)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	frames, err := d.Decode(NewByteBuf([]byte{0xC8}))
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+	frames, err = d.Decode(NewByteBuf(append([]byte{0x01}, payload...)))
+	assert.NoError(t, err)
+	assert.Len(t, frames, 1)
+	assert.Equal(t, payload, frames[0].BytesCopy())
+}
+
+// Oversized frames are reported once and skipped, even when their bytes
+// arrive later; the next frame decodes normally.
+func TestLengthFieldDecoder_TooLongDiscards(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:      4,
+		LengthFieldLength:   1,
+		InitialBytesToStrip: 1,
+	})
+	frames, err := d.Decode(NewByteBuf([]byte{0x05, 'x', 'x'}))
+	assert.ErrorIs(t, err, ErrFrameTooLong)
+	assert.Empty(t, frames)
+
+	frames, err = d.Decode(NewByteBuf([]byte{'x', 'x', 'x', 0x01, 'y'}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"y"}, frameStrings(frames))
+}
+
+// A frame shorter than InitialBytesToStrip is corrupted; its header alone
+// is enough to tell, and the rest of it is skipped as it arrives.
+func TestLengthFieldDecoder_StripBeyondFrame(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:      100,
+		LengthFieldLength:   1,
+		InitialBytesToStrip: 4,
+	})
+	frames, err := d.Decode(NewByteBuf([]byte{1}))
+	assert.ErrorIs(t, err, ErrCorruptedFrame)
+	assert.Empty(t, frames)
+	assert.Equal(t, 0, d.Buffered())
+
+	frames, err = d.Decode(NewByteBuf([]byte{'x', 4, 'a', 'b', 'c'}))
+	assert.NoError(t, err)
+	assert.Empty(t, frames)
+	assert.Equal(t, 4, d.Buffered())
+}
+
+// A length beyond int range cannot be skipped: the decoder reports the
+// stream as corrupted until Reset.
+func TestLengthFieldDecoder_HugeLengthCorrupts(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:    64,
+		LengthFieldLength: 8,
+	})
+	frames, err := d.Decode(NewByteBuf([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 'x', 'x'}))
+	assert.ErrorIs(t, err, ErrCorruptedFrame)
+	assert.Empty(t, frames)
+	assert.Equal(t, 0, d.Buffered())
+
+	in := NewByteBuf([]byte{0, 0, 0, 0, 0, 0, 0, 1, 'y'})
+	frames, err = d.Decode(in)
+	assert.ErrorIs(t, err, ErrCorruptedFrame)
+	assert.Empty(t, frames)
+	assert.Equal(t, 0, in.ReadableBytes())
+	assert.Equal(t, 0, d.Buffered())
+
+	d.Reset()
+	frames, err = d.Decode(NewByteBuf([]byte{0, 0, 0, 0, 0, 0, 0, 1, 'y'}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"\x00\x00\x00\x00\x00\x00\x00\x01y"}, frameStrings(frames))
+}
+
+// A length the wire width can express is too long, and the frame is
+// discarded rather than just its header.
+func TestLengthFieldDecoder_LargeLengthDiscards(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:    64,
+		LengthFieldLength: 4,
+	})
+	frames, err := d.Decode(NewByteBuf([]byte{0x80, 0x00, 0x00, 0x00, 'x', 'x'}))
+	assert.ErrorIs(t, err, ErrFrameTooLong)
+	assert.Empty(t, frames)
+	assert.Equal(t, 0, d.Buffered())
+	assert.Equal(t, 0x80000000+4-6, d.bytesToDiscard)
+}
+
+// Close hands the cumulation and its tails back to the allocator.
+func TestLengthFieldDecoder_CloseReleasesCumulation(t *testing.T) {
+	alloc := NewTrackingAllocator(Pooled)
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:    64,
+		LengthFieldLength: 1,
+		Allocator:         alloc,
+	})
+	d.cumulation.WriteBytes([]byte{0x05, 'a'})
+	assert.NotZero(t, alloc.Outstanding())
+	assert.NoError(t, d.Close())
+	assert.Zero(t, alloc.Outstanding())
+}
+
+// A length that makes the frame shorter than its header is corrupted.
+func TestLengthFieldDecoder_Corrupted(t *testing.T) {
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:    64,
+		LengthFieldLength: 2,
+		LengthAdjustment:  -4,
+	})
+	_, err := d.Decode(NewByteBuf([]byte{0x00, 0x01, 0x00}))
+	assert.ErrorIs(t, err, ErrCorruptedFrame)
+	assert.Equal(t, 1, d.Buffered())
+}
+
+// Unsupported configurations panic at construction.
+func TestLengthFieldDecoder_InvalidConfig_Panics(t *testing.T) {
+	assert.Panics(t, func() { NewLengthFieldFrameDecoder(LengthFieldConfig{MaxFrameLength: 8, LengthFieldLength: 5}) })
+	assert.Panics(t, func() { NewLengthFieldFrameDecoder(LengthFieldConfig{LengthFieldLength: 2}) })
+}
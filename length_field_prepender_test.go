@@ -0,0 +1,101 @@
+package buf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The output is header + aliased payload in two components.
+func TestLengthFieldPrepender_TwoComponentsZeroCopy(t *testing.T) {
+	p := NewLengthFieldPrepender(LengthFieldPrependerConfig{LengthFieldLength: 2})
+	payload := []byte("hello")
+	msg := NewSharedByteBuf(payload)
+	out, err := p.Encode(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, msg.ReadableBytes())
+
+	d := asDefault(out)
+	assert.Equal(t, 2, len(d.components))
+	assert.Equal(t, []byte{0x00, 0x05}, d.components[0].data)
+	payload[0] = 'H'
+	assert.Equal(t, []byte("\x00\x05Hello"), out.BytesCopy())
+}
+
+// Byte order, self-inclusive length and adjustment are honoured.
+func TestLengthFieldPrepender_Options(t *testing.T) {
+	p := NewLengthFieldPrepender(LengthFieldPrependerConfig{
+		LengthFieldLength:         4,
+		ByteOrder:                 binary.LittleEndian,
+		LengthIncludesLengthField: true,
+		LengthAdjustment:          1,
+	})
+	out, err := p.Encode(NewByteBuf([]byte("ab")))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x07, 0x00, 0x00, 0x00, 'a', 'b'}, out.BytesCopy())
+
+	p3 := NewLengthFieldPrepender(LengthFieldPrependerConfig{LengthFieldLength: 3})
+	out, err = p3.Encode(NewByteBuf(make([]byte, 0x0102)))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x02}, out.BytesCopy()[:3])
+}
+
+// A varint prefix that counts itself picks the width that fits.
+func TestLengthFieldPrepender_VarintSelfInclusive(t *testing.T) {
+	p := NewLengthFieldPrepender(LengthFieldPrependerConfig{
+		LengthFieldLength:         LengthFieldVarint,
+		LengthIncludesLengthField: true,
+	})
+	out, err := p.Encode(NewByteBuf(make([]byte, 126)))
+	assert.NoError(t, err)
+	// 126 + 1 = 127 fits one byte; 127 + 1 = 128 would need two.
+	assert.Equal(t, byte(127), out.BytesCopy()[0])
+
+	out, err = p.Encode(NewByteBuf(make([]byte, 127)))
+	assert.NoError(t, err)
+	v, err := out.(VarintCodec).ReadVarUInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(129), v)
+}
+
+// Lengths that do not fit or go negative are rejected without output.
+func TestLengthFieldPrepender_Errors(t *testing.T) {
+	p := NewLengthFieldPrepender(LengthFieldPrependerConfig{LengthFieldLength: 1})
+	_, err := p.Encode(NewByteBuf(make([]byte, 256)))
+	assert.ErrorIs(t, err, ErrFrameTooLong)
+
+	neg := NewLengthFieldPrepender(LengthFieldPrependerConfig{LengthFieldLength: 2, LengthAdjustment: -3})
+	dst := NewCompositeByteBuf()
+	msg := NewByteBuf([]byte("ab"))
+	assert.ErrorIs(t, neg.EncodeTo(dst, msg), ErrCorruptedFrame)
+	assert.Equal(t, 0, dst.ReadableBytes())
+	assert.Equal(t, 2, msg.ReadableBytes())
+
+	assert.Panics(t, func() { NewLengthFieldPrepender(LengthFieldPrependerConfig{LengthFieldLength: 5}) })
+}
+
+// Frames batched into one composite decode back with the matching decoder
+// and drain in one WriteTo.
+func TestLengthFieldPrepender_RoundTripWithDecoder(t *testing.T) {
+	p := NewLengthFieldPrepender(LengthFieldPrependerConfig{LengthFieldLength: LengthFieldVarint})
+	batch := NewCompositeByteBuf()
+	for _, s := range []string{"one", "two", "three"} {
+		assert.NoError(t, p.EncodeTo(batch, NewByteBufString(s)))
+	}
+	assert.Equal(t, 6, len(asDefault(batch).components))
+
+	var wire bytes.Buffer
+	_, err := batch.WriteTo(&wire)
+	assert.NoError(t, err)
+
+	d := NewLengthFieldFrameDecoder(LengthFieldConfig{
+		MaxFrameLength:      64,
+		LengthFieldLength:   LengthFieldVarint,
+		InitialBytesToStrip: 1,
+	})
+	frames, err := d.Decode(NewByteBuf(wire.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two", "three"}, frameStrings(frames))
+}
@@ -0,0 +1,713 @@
+package buf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedType is returned by Marshal and Unmarshal for values or
+// fields whose Go type has no wire mapping.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// ErrInvalidTag is returned when a bytebuf struct tag cannot be parsed or
+// does not apply to the field's type.
+var ErrInvalidTag = errors.New("invalid bytebuf tag")
+
+// ErrValueOverflow is returned when a value does not fit its wire width.
+var ErrValueOverflow = errors.New("value overflows wire width")
+
+// Marshal writes the exported fields of the struct v (or pointer to struct)
+// to bb in declaration order. The wire layout of each field is controlled by
+// its `bytebuf` struct tag, a comma-separated list of:
+//
+//	u8 … u64     unsigned wire width: u8, u16, u24, u32 or u64
+//	i8 … i64     signed wire width, sign-extended on decode
+//	le, be       byte order of the field (default be)
+//	varint       LEB128 varint; zig-zag for signed integer fields
+//	len=W        length prefix for strings and slices, W being a width
+//	             such as u16 or varint; slices count elements
+//	skip=N       N zero bytes written before the field, skipped on decode
+//
+// A tag of "-" excludes the field. Untagged integers, floats and bools use
+// their natural width in big-endian order; int, uint and uintptr are 8
+// bytes wide on every platform. Nested structs and fixed arrays are encoded
+// inline; strings and slices require a len= prefix. Type plans are cached,
+// so only the first call for a type pays for reflection over its tags. On
+// error bb may hold a partial encoding. The order of an OrderedByteBuf does
+// not apply; fields not tagged le are always big-endian.
+func Marshal(bb ByteBuf, v any) error {
+	if bb == nil || v == nil {
+		return ErrNilObject
+	}
+	bb = unorderedView(bb)
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ErrNilObject
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return ErrUnsupportedType
+	}
+	plan, err := planFor(rv.Type())
+	if err != nil {
+		return err
+	}
+	return plan.encode(bb, rv)
+}
+
+// Unmarshal reads into the struct pointed to by v using the layout described
+// at Marshal. ErrInsufficientSize is returned when bb runs out of readable
+// bytes; the reader index is then left where decoding stopped.
+func Unmarshal(bb ByteBuf, v any) (err error) {
+	if bb == nil || v == nil {
+		return ErrNilObject
+	}
+	bb = unorderedView(bb)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrNilObject
+	}
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return ErrUnsupportedType
+	}
+	plan, err := planFor(rv.Type())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if r == ErrInsufficientSize || r == ErrCompositeOutOfRange {
+				err = ErrInsufficientSize
+				return
+			}
+			panic(r)
+		}
+	}()
+	return plan.decode(bb, rv)
+}
+
+type encodeFunc func(bb ByteBuf, v reflect.Value) error
+type decodeFunc func(bb ByteBuf, v reflect.Value) error
+
+// wireOpts is the parsed form of a bytebuf struct tag.
+type wireOpts struct {
+	ignore   bool
+	width    int // wire width in bytes, 0 for the type's natural width
+	signed   bool
+	le       bool
+	varint   bool
+	lenWidth int // length prefix width, LengthFieldVarint for a varint
+	skip     int
+}
+
+type fieldPlan struct {
+	index int
+	skip  int
+	enc   encodeFunc
+	dec   decodeFunc
+}
+
+type structPlan struct {
+	fields []fieldPlan
+}
+
+type planEntry struct {
+	plan *structPlan
+	err  error
+}
+
+// planCache maps a struct reflect.Type to its planEntry.
+var planCache sync.Map
+
+func planFor(t reflect.Type) (*structPlan, error) {
+	if e, ok := planCache.Load(t); ok {
+		entry := e.(planEntry)
+		return entry.plan, entry.err
+	}
+	building := map[reflect.Type]*structPlan{}
+	plan, err := buildPlan(t, building)
+	if err != nil {
+		e, _ := planCache.LoadOrStore(t, planEntry{err: err})
+		entry := e.(planEntry)
+		return entry.plan, entry.err
+	}
+	// Nested plans are cached only now, as they may point at plans that
+	// were still being built.
+	for nt, np := range building {
+		if nt != t {
+			planCache.LoadOrStore(nt, planEntry{plan: np})
+		}
+	}
+	e, _ := planCache.LoadOrStore(t, planEntry{plan: plan})
+	entry := e.(planEntry)
+	return entry.plan, entry.err
+}
+
+// buildPlan builds the plan of t, resolving nested struct plans up front.
+// building maps the types whose plans are under construction to those
+// plans, so a type that contains itself through a slice refers to its own
+// plan instead of recursing.
+func buildPlan(t reflect.Type, building map[reflect.Type]*structPlan) (*structPlan, error) {
+	plan := &structPlan{}
+	building[t] = plan
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		opts, err := parseTag(f.Tag.Get("bytebuf"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s.%s", err, t.Name(), f.Name)
+		}
+		if opts.ignore {
+			continue
+		}
+		enc, dec, err := codecFor(f.Type, opts, building)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s.%s", err, t.Name(), f.Name)
+		}
+		plan.fields = append(plan.fields, fieldPlan{index: i, skip: opts.skip, enc: enc, dec: dec})
+	}
+	return plan, nil
+}
+
+// nestedPlan returns the plan of a struct nested in a plan being built.
+func nestedPlan(t reflect.Type, building map[reflect.Type]*structPlan) (*structPlan, error) {
+	if plan, ok := building[t]; ok {
+		return plan, nil
+	}
+	if e, ok := planCache.Load(t); ok {
+		entry := e.(planEntry)
+		return entry.plan, entry.err
+	}
+	return buildPlan(t, building)
+}
+
+func (p *structPlan) encode(bb ByteBuf, v reflect.Value) error {
+	for i := range p.fields {
+		f := &p.fields[i]
+		for range f.skip {
+			bb.AppendByte(0)
+		}
+		if err := f.enc(bb, v.Field(f.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *structPlan) decode(bb ByteBuf, v reflect.Value) error {
+	for i := range p.fields {
+		f := &p.fields[i]
+		bb.Skip(f.skip)
+		if err := f.dec(bb, v.Field(f.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseTag(tag string) (wireOpts, error) {
+	var o wireOpts
+	if tag == "" {
+		return o, nil
+	}
+	if tag == "-" {
+		o.ignore = true
+		return o, nil
+	}
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case part == "le":
+			o.le = true
+		case part == "be":
+			o.le = false
+		case part == "varint":
+			o.varint = true
+		case strings.HasPrefix(part, "len="):
+			spec := strings.TrimPrefix(part, "len=")
+			if spec == "varint" {
+				o.lenWidth = LengthFieldVarint
+				continue
+			}
+			w, _, ok := parseWidth(spec)
+			if !ok {
+				return o, ErrInvalidTag
+			}
+			o.lenWidth = w
+		case strings.HasPrefix(part, "skip="):
+			n, err := strconv.Atoi(strings.TrimPrefix(part, "skip="))
+			if err != nil || n < 0 {
+				return o, ErrInvalidTag
+			}
+			o.skip = n
+		default:
+			w, signed, ok := parseWidth(part)
+			if !ok {
+				return o, ErrInvalidTag
+			}
+			o.width, o.signed = w, signed
+		}
+	}
+	return o, nil
+}
+
+// parseWidth parses u8/u16/u24/u32/u64 and their i-prefixed signed forms.
+func parseWidth(s string) (width int, signed bool, ok bool) {
+	if len(s) < 2 || (s[0] != 'u' && s[0] != 'i') {
+		return 0, false, false
+	}
+	switch s[1:] {
+	case "8":
+		width = 1
+	case "16":
+		width = 2
+	case "24":
+		width = 3
+	case "32":
+		width = 4
+	case "64":
+		width = 8
+	default:
+		return 0, false, false
+	}
+	return width, s[0] == 'i', true
+}
+
+func codecFor(t reflect.Type, o wireOpts, building map[reflect.Type]*structPlan) (encodeFunc, decodeFunc, error) {
+	switch t.Kind() {
+	case reflect.Bool:
+		if o.varint || o.lenWidth != 0 || o.width > 1 {
+			return nil, nil, ErrInvalidTag
+		}
+		return encodeBool, decodeBool, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if o.lenWidth != 0 {
+			return nil, nil, ErrInvalidTag
+		}
+		return intCodec(t, o)
+	case reflect.Float32, reflect.Float64:
+		if o.varint || o.lenWidth != 0 || o.width != 0 {
+			return nil, nil, ErrInvalidTag
+		}
+		return floatCodec(t.Kind() == reflect.Float32, o.le)
+	case reflect.String:
+		if o.lenWidth == 0 || o.width != 0 || o.varint {
+			return nil, nil, ErrInvalidTag
+		}
+		return stringCodec(o)
+	case reflect.Slice:
+		if o.lenWidth == 0 {
+			return nil, nil, ErrInvalidTag
+		}
+		return sliceCodec(t, o, building)
+	case reflect.Array:
+		if o.lenWidth != 0 {
+			return nil, nil, ErrInvalidTag
+		}
+		return arrayCodec(t, o, building)
+	case reflect.Struct:
+		if o.lenWidth != 0 || o.width != 0 || o.varint {
+			return nil, nil, ErrInvalidTag
+		}
+		plan, err := nestedPlan(t, building)
+		if err != nil {
+			return nil, nil, err
+		}
+		return plan.encode, plan.decode, nil
+	}
+	return nil, nil, ErrUnsupportedType
+}
+
+func encodeBool(bb ByteBuf, v reflect.Value) error {
+	if v.Bool() {
+		bb.AppendByte(1)
+	} else {
+		bb.AppendByte(0)
+	}
+	return nil
+}
+
+func decodeBool(bb ByteBuf, v reflect.Value) error {
+	v.SetBool(bb.MustReadByte() != 0)
+	return nil
+}
+
+func isSignedKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func intCodec(t reflect.Type, o wireOpts) (encodeFunc, decodeFunc, error) {
+	signedField := isSignedKind(t.Kind())
+	if o.varint {
+		if o.width != 0 {
+			return nil, nil, ErrInvalidTag
+		}
+		if signedField {
+			enc := func(bb ByteBuf, v reflect.Value) error {
+				WriteVarInt64(bb, v.Int())
+				return nil
+			}
+			dec := func(bb ByteBuf, v reflect.Value) error {
+				x, err := ReadVarInt64(bb)
+				if err != nil {
+					return err
+				}
+				if v.OverflowInt(x) {
+					return ErrValueOverflow
+				}
+				v.SetInt(x)
+				return nil
+			}
+			return enc, dec, nil
+		}
+		enc := func(bb ByteBuf, v reflect.Value) error {
+			WriteVarUInt64(bb, v.Uint())
+			return nil
+		}
+		dec := func(bb ByteBuf, v reflect.Value) error {
+			u, err := ReadVarUInt64(bb)
+			if err != nil {
+				return err
+			}
+			if v.OverflowUint(u) {
+				return ErrValueOverflow
+			}
+			v.SetUint(u)
+			return nil
+		}
+		return enc, dec, nil
+	}
+
+	width, signedWire := o.width, o.signed
+	if width == 0 {
+		width, signedWire = naturalWidth(t), signedField
+	}
+	le := o.le
+	enc := func(bb ByteBuf, v reflect.Value) error {
+		var u uint64
+		if signedField {
+			x := v.Int()
+			if signedWire && !fitsSigned(x, width) || !signedWire && (x < 0 || !fitsUnsigned(uint64(x), width)) {
+				return ErrValueOverflow
+			}
+			u = uint64(x)
+		} else {
+			u = v.Uint()
+			if signedWire && u > math.MaxInt64 || !fitsUnsigned(u, width) || signedWire && !fitsSigned(int64(u), width) {
+				return ErrValueOverflow
+			}
+		}
+		writeUint(bb, u, width, le)
+		return nil
+	}
+	dec := func(bb ByteBuf, v reflect.Value) error {
+		u := readUint(bb, width, le)
+		if signedWire {
+			shift := 64 - 8*width
+			x := int64(u<<shift) >> shift
+			if signedField {
+				if v.OverflowInt(x) {
+					return ErrValueOverflow
+				}
+				v.SetInt(x)
+				return nil
+			}
+			if x < 0 || v.OverflowUint(uint64(x)) {
+				return ErrValueOverflow
+			}
+			v.SetUint(uint64(x))
+			return nil
+		}
+		if signedField {
+			if u > math.MaxInt64 || v.OverflowInt(int64(u)) {
+				return ErrValueOverflow
+			}
+			v.SetInt(int64(u))
+			return nil
+		}
+		if v.OverflowUint(u) {
+			return ErrValueOverflow
+		}
+		v.SetUint(u)
+		return nil
+	}
+	return enc, dec, nil
+}
+
+// naturalWidth is the wire width of an untagged integer type. The
+// platform-sized kinds are fixed at 8 bytes so the layout does not depend
+// on the architecture.
+func naturalWidth(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Int, reflect.Uint, reflect.Uintptr:
+		return 8
+	}
+	return int(t.Size())
+}
+
+func floatCodec(is32, le bool) (encodeFunc, decodeFunc, error) {
+	if is32 {
+		enc := func(bb ByteBuf, v reflect.Value) error {
+			writeUint(bb, uint64(math.Float32bits(float32(v.Float()))), 4, le)
+			return nil
+		}
+		dec := func(bb ByteBuf, v reflect.Value) error {
+			v.SetFloat(float64(math.Float32frombits(uint32(readUint(bb, 4, le)))))
+			return nil
+		}
+		return enc, dec, nil
+	}
+	enc := func(bb ByteBuf, v reflect.Value) error {
+		writeUint(bb, math.Float64bits(v.Float()), 8, le)
+		return nil
+	}
+	dec := func(bb ByteBuf, v reflect.Value) error {
+		v.SetFloat(math.Float64frombits(readUint(bb, 8, le)))
+		return nil
+	}
+	return enc, dec, nil
+}
+
+func stringCodec(o wireOpts) (encodeFunc, decodeFunc, error) {
+	enc := func(bb ByteBuf, v reflect.Value) error {
+		s := v.String()
+		if err := writeLength(bb, len(s), o); err != nil {
+			return err
+		}
+		bb.WriteString(s)
+		return nil
+	}
+	dec := func(bb ByteBuf, v reflect.Value) error {
+		n, err := readLength(bb, o)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(bb.ReadBytes(n)))
+		return nil
+	}
+	return enc, dec, nil
+}
+
+func sliceCodec(t reflect.Type, o wireOpts, building map[reflect.Type]*structPlan) (encodeFunc, decodeFunc, error) {
+	if t.Elem().Kind() == reflect.Uint8 && o.width == 0 && !o.varint {
+		enc := func(bb ByteBuf, v reflect.Value) error {
+			bs := v.Bytes()
+			if err := writeLength(bb, len(bs), o); err != nil {
+				return err
+			}
+			bb.WriteBytes(bs)
+			return nil
+		}
+		dec := func(bb ByteBuf, v reflect.Value) error {
+			n, err := readLength(bb, o)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte(nil), bb.ReadBytes(n)...))
+			return nil
+		}
+		return enc, dec, nil
+	}
+	elemOpts := o
+	elemOpts.lenWidth, elemOpts.skip = 0, 0
+	elemEnc, elemDec, err := codecFor(t.Elem(), elemOpts, building)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := func(bb ByteBuf, v reflect.Value) error {
+		n := v.Len()
+		if err := writeLength(bb, n, o); err != nil {
+			return err
+		}
+		for i := range n {
+			if err := elemEnc(bb, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	dec := func(bb ByteBuf, v reflect.Value) error {
+		n, err := readLength(bb, o)
+		if err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(t, n, n)
+		for i := range n {
+			if err := elemDec(bb, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return enc, dec, nil
+}
+
+func arrayCodec(t reflect.Type, o wireOpts, building map[reflect.Type]*structPlan) (encodeFunc, decodeFunc, error) {
+	n := t.Len()
+	if t.Elem().Kind() == reflect.Uint8 && o.width == 0 && !o.varint {
+		enc := func(bb ByteBuf, v reflect.Value) error {
+			if v.CanAddr() {
+				bb.WriteBytes(v.Bytes())
+				return nil
+			}
+			for i := range n {
+				bb.AppendByte(byte(v.Index(i).Uint()))
+			}
+			return nil
+		}
+		dec := func(bb ByteBuf, v reflect.Value) error {
+			reflect.Copy(v, reflect.ValueOf(bb.ReadBytes(n)))
+			return nil
+		}
+		return enc, dec, nil
+	}
+	elemOpts := o
+	elemOpts.skip = 0
+	elemEnc, elemDec, err := codecFor(t.Elem(), elemOpts, building)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := func(bb ByteBuf, v reflect.Value) error {
+		for i := range n {
+			if err := elemEnc(bb, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	dec := func(bb ByteBuf, v reflect.Value) error {
+		for i := range n {
+			if err := elemDec(bb, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return enc, dec, nil
+}
+
+func fitsUnsigned(u uint64, width int) bool {
+	return width == 8 || u < 1<<(8*width)
+}
+
+func fitsSigned(x int64, width int) bool {
+	if width == 8 {
+		return true
+	}
+	limit := int64(1) << (8*width - 1)
+	return x >= -limit && x < limit
+}
+
+// unorderedView returns the buffer behind an OrderedByteBuf, whose
+// unsuffixed accessors are big-endian, so writeUint and readUint keep the
+// wire order of the tags.
+func unorderedView(bb ByteBuf) ByteBuf {
+	if o, ok := bb.(OrderedByteBuf); ok {
+		return o.Unwrap()
+	}
+	return bb
+}
+
+// writeUint writes the low width bytes of u in the requested byte order.
+func writeUint(bb ByteBuf, u uint64, width int, le bool) {
+	switch width {
+	case 1:
+		bb.AppendByte(byte(u))
+	case 2:
+		if le {
+			bb.WriteUInt16LE(uint16(u))
+		} else {
+			bb.WriteUInt16(uint16(u))
+		}
+	case 3:
+		var tmp [3]byte
+		if le {
+			tmp[0], tmp[1], tmp[2] = byte(u), byte(u>>8), byte(u>>16)
+		} else {
+			tmp[0], tmp[1], tmp[2] = byte(u>>16), byte(u>>8), byte(u)
+		}
+		bb.WriteBytes(tmp[:])
+	case 4:
+		if le {
+			bb.WriteUInt32LE(uint32(u))
+		} else {
+			bb.WriteUInt32(uint32(u))
+		}
+	case 8:
+		if le {
+			bb.WriteUInt64LE(u)
+		} else {
+			bb.WriteUInt64(u)
+		}
+	}
+}
+
+// readUint reads a width-byte unsigned integer in the requested byte order.
+func readUint(bb ByteBuf, width int, le bool) uint64 {
+	switch width {
+	case 1:
+		return uint64(bb.MustReadByte())
+	case 2:
+		if le {
+			return uint64(bb.ReadUInt16LE())
+		}
+		return uint64(bb.ReadUInt16())
+	case 3:
+		bs := bb.ReadBytes(3)
+		if le {
+			return uint64(bs[0]) | uint64(bs[1])<<8 | uint64(bs[2])<<16
+		}
+		return uint64(bs[0])<<16 | uint64(bs[1])<<8 | uint64(bs[2])
+	case 4:
+		if le {
+			return uint64(bb.ReadUInt32LE())
+		}
+		return uint64(bb.ReadUInt32())
+	default:
+		if le {
+			return bb.ReadUInt64LE()
+		}
+		return bb.ReadUInt64()
+	}
+}
+
+func writeLength(bb ByteBuf, n int, o wireOpts) error {
+	if o.lenWidth == LengthFieldVarint {
+		WriteVarUInt64(bb, uint64(n))
+		return nil
+	}
+	if !fitsUnsigned(uint64(n), o.lenWidth) {
+		return ErrValueOverflow
+	}
+	writeUint(bb, uint64(n), o.lenWidth, o.le)
+	return nil
+}
+
+// readLength reads a length prefix and rejects lengths larger than the
+// readable region, so a hostile prefix cannot force a huge allocation.
+func readLength(bb ByteBuf, o wireOpts) (int, error) {
+	var n uint64
+	if o.lenWidth == LengthFieldVarint {
+		var err error
+		if n, err = ReadVarUInt64(bb); err != nil {
+			return 0, err
+		}
+	} else {
+		n = readUint(bb, o.lenWidth, o.le)
+	}
+	if n > uint64(bb.ReadableBytes()) {
+		return 0, ErrInsufficientSize
+	}
+	return int(n), nil
+}
@@ -0,0 +1,232 @@
+package buf
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type marshalHeader struct {
+	Magic   uint16
+	Flags   uint8
+	Length  uint32 `bytebuf:"u24,le"`
+	Version int8
+}
+
+type marshalMessage struct {
+	Header   marshalHeader
+	ID       int64  `bytebuf:"varint"`
+	Seq      uint32 `bytebuf:"varint"`
+	Delta    int32  `bytebuf:"i16"`
+	Ratio    float32
+	Name     string   `bytebuf:"len=u16"`
+	Payload  []byte   `bytebuf:"len=varint"`
+	Ports    []uint16 `bytebuf:"len=u8,le"`
+	Reserved [2]byte  `bytebuf:"skip=4"`
+	Matrix   [2]int16
+	Enabled  bool
+	Ignored  string `bytebuf:"-"`
+	internal int
+}
+
+// A message survives a round trip and ignored fields stay untouched.
+func TestMarshal_RoundTrip(t *testing.T) {
+	in := marshalMessage{
+		Header:   marshalHeader{Magic: 0xCAFE, Flags: 3, Length: 0x010203, Version: -1},
+		ID:       -42,
+		Seq:      300,
+		Delta:    -1000,
+		Ratio:    1.25,
+		Name:     "frame",
+		Payload:  []byte{9, 8, 7},
+		Ports:    []uint16{80, 443},
+		Reserved: [2]byte{0xAA, 0xBB},
+		Matrix:   [2]int16{-1, 2},
+		Enabled:  true,
+		Ignored:  "x",
+		internal: 5,
+	}
+	b := EmptyByteBuf()
+	assert.NoError(t, Marshal(b, &in))
+
+	var out marshalMessage
+	assert.NoError(t, Unmarshal(b, &out))
+	in.Ignored, in.internal = "", 0
+	assert.Equal(t, in, out)
+	assert.Equal(t, 0, b.ReadableBytes())
+}
+
+// Tags drive the exact wire layout.
+func TestMarshal_WireLayout(t *testing.T) {
+	type layout struct {
+		A uint32 `bytebuf:"u24,le"`
+		B int16  `bytebuf:"le"`
+		C string `bytebuf:"len=u8,skip=1"`
+		D int32  `bytebuf:"varint"`
+	}
+	b := EmptyByteBuf()
+	assert.NoError(t, Marshal(b, layout{A: 0x010203, B: -2, C: "hi", D: -1}))
+	assert.Equal(t, []byte{0x03, 0x02, 0x01, 0xFE, 0xFF, 0x00, 0x02, 'h', 'i', 0x01}, b.Bytes())
+
+	// Platform-sized integers are 8 bytes wide on every architecture.
+	type sized struct {
+		I int
+		U uint
+		P uintptr
+	}
+	b = EmptyByteBuf()
+	assert.NoError(t, Marshal(b, sized{I: -1, U: 2, P: 3}))
+	assert.Equal(t, []byte{
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0, 0, 0, 0, 0, 0, 0, 2,
+		0, 0, 0, 0, 0, 0, 0, 3,
+	}, b.Bytes())
+	var out sized
+	assert.NoError(t, Unmarshal(b, &out))
+	assert.Equal(t, sized{I: -1, U: 2, P: 3}, out)
+}
+
+// The order of an OrderedByteBuf does not change the tagged layout.
+func TestMarshal_OrderedView(t *testing.T) {
+	type layout struct {
+		A uint16
+		B uint32 `bytebuf:"le"`
+		C float32
+	}
+	in := layout{A: 0x0102, B: 0x03040506, C: 1.5}
+	plain := EmptyByteBuf()
+	assert.NoError(t, Marshal(plain, in))
+	le := Order(EmptyByteBuf(), binary.LittleEndian)
+	assert.NoError(t, Marshal(le, in))
+	assert.Equal(t, plain.Bytes(), le.Bytes())
+	assert.Equal(t, []byte{0x01, 0x02, 0x06, 0x05, 0x04, 0x03}, le.Bytes()[:6])
+
+	var out layout
+	assert.NoError(t, Unmarshal(le, &out))
+	assert.Equal(t, in, out)
+
+	// A view of a buffer without VarintCodec takes the byte-wise path.
+	type tagged struct {
+		V int32 `bytebuf:"varint"`
+	}
+	foreign := Order(plainByteBuf{EmptyByteBuf()}, binary.LittleEndian)
+	assert.NoError(t, Marshal(foreign, tagged{V: -1}))
+	assert.Equal(t, []byte{0x01}, foreign.Bytes())
+	var got tagged
+	assert.NoError(t, Unmarshal(foreign, &got))
+	assert.Equal(t, int32(-1), got.V)
+}
+
+// Values that do not fit their wire width are rejected.
+func TestMarshal_Overflow(t *testing.T) {
+	type narrow struct {
+		V uint32 `bytebuf:"u8"`
+	}
+	assert.ErrorIs(t, Marshal(EmptyByteBuf(), narrow{V: 256}), ErrValueOverflow)
+
+	type signed struct {
+		V int64 `bytebuf:"i16"`
+	}
+	assert.ErrorIs(t, Marshal(EmptyByteBuf(), signed{V: math.MinInt16 - 1}), ErrValueOverflow)
+	assert.NoError(t, Marshal(EmptyByteBuf(), signed{V: math.MinInt16}))
+
+	type short struct {
+		V int8 `bytebuf:"i16"`
+	}
+	var s short
+	assert.ErrorIs(t, Unmarshal(NewByteBuf([]byte{0x01, 0x00}), &s), ErrValueOverflow)
+}
+
+// Truncated input reports ErrInsufficientSize instead of panicking, and a
+// hostile length prefix cannot force a large allocation.
+func TestUnmarshal_Insufficient(t *testing.T) {
+	var h marshalHeader
+	assert.ErrorIs(t, Unmarshal(NewByteBuf([]byte{0xCA, 0xFE, 0x01}), &h), ErrInsufficientSize)
+
+	type blob struct {
+		Data []uint64 `bytebuf:"len=u32"`
+	}
+	var bl blob
+	assert.ErrorIs(t, Unmarshal(NewByteBuf([]byte{0xFF, 0xFF, 0xFF, 0xFF}), &bl), ErrInsufficientSize)
+}
+
+// Composite sources decode across component boundaries.
+func TestUnmarshal_Composite(t *testing.T) {
+	src := EmptyByteBuf()
+	in := marshalHeader{Magic: 1, Flags: 2, Length: 3, Version: 4}
+	assert.NoError(t, Marshal(src, in))
+	bs := src.Bytes()
+	c := NewCompositeByteBuf(bbBytes(bs[:1]), bbBytes(bs[1:4]), bbBytes(bs[4:]))
+
+	var out marshalHeader
+	assert.NoError(t, Unmarshal(c, &out))
+	assert.Equal(t, in, out)
+}
+
+// Bad tags and unsupported types are reported with the field name.
+func TestMarshal_InvalidTagsAndTypes(t *testing.T) {
+	type badTag struct {
+		V uint16 `bytebuf:"u12"`
+	}
+	err := Marshal(EmptyByteBuf(), badTag{})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+	assert.Contains(t, err.Error(), "badTag.V")
+
+	type noLen struct {
+		S string
+	}
+	assert.ErrorIs(t, Marshal(EmptyByteBuf(), noLen{}), ErrInvalidTag)
+
+	type unsupported struct {
+		M map[string]int
+	}
+	assert.ErrorIs(t, Marshal(EmptyByteBuf(), unsupported{}), ErrUnsupportedType)
+
+	assert.ErrorIs(t, Marshal(EmptyByteBuf(), 5), ErrUnsupportedType)
+	assert.ErrorIs(t, Unmarshal(EmptyByteBuf(), marshalHeader{}), ErrNilObject)
+}
+
+// Plans are built once per type and reused; nested plans are resolved
+// with the outer one.
+func TestMarshal_PlanCached(t *testing.T) {
+	assert.NoError(t, Marshal(EmptyByteBuf(), marshalHeader{}))
+	p1, _ := planFor(reflect.TypeFor[marshalHeader]())
+	p2, _ := planFor(reflect.TypeFor[marshalHeader]())
+	assert.Same(t, p1, p2)
+
+	type inner struct{ V uint8 }
+	type outer struct {
+		In []inner `bytebuf:"len=u8"`
+	}
+	_, err := planFor(reflect.TypeFor[outer]())
+	assert.NoError(t, err)
+	_, cached := planCache.Load(reflect.TypeFor[inner]())
+	assert.True(t, cached)
+
+	// A bad nested type fails the outer plan even before any element is
+	// encoded.
+	type badInner struct{ M map[int]int }
+	type badOuter struct {
+		In []badInner `bytebuf:"len=u8"`
+	}
+	assert.ErrorIs(t, Marshal(EmptyByteBuf(), badOuter{}), ErrUnsupportedType)
+}
+
+// Recursive types refer to their own plan.
+type marshalTree struct {
+	Value    uint8
+	Children []marshalTree `bytebuf:"len=u8"`
+}
+
+func TestMarshal_RecursiveType(t *testing.T) {
+	in := marshalTree{Value: 1, Children: []marshalTree{{Value: 2}, {Value: 3, Children: []marshalTree{{Value: 4}}}}}
+	b := EmptyByteBuf()
+	assert.NoError(t, Marshal(b, in))
+	var out marshalTree
+	assert.NoError(t, Unmarshal(b, &out))
+	assert.Equal(t, uint8(4), out.Children[1].Children[0].Value)
+	assert.Equal(t, []marshalTree{}, out.Children[0].Children)
+}
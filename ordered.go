@@ -0,0 +1,123 @@
+package buf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// OrderedByteBuf is a ByteBuf view whose unsuffixed multi-byte accessors,
+// such as ReadUInt32, WriteInt64, TryReadFloat32, GetUInt16 and SetInt32,
+// use the byte order reported by Order. The LE-suffixed accessors keep
+// their explicit little-endian meaning. The view shares indices, marks,
+// storage and refcount with the wrapped buffer; chained calls and derived
+// views (Slice, Duplicate, ReadSlice, ReadByteBuf, Clone) keep the order.
+type OrderedByteBuf interface {
+	ByteBuf
+
+	// Order returns the byte order of the unsuffixed accessors.
+	Order() binary.ByteOrder
+	// Unwrap returns the wrapped buffer.
+	Unwrap() ByteBuf
+}
+
+//go:generate go run ./internal/orderedgen
+
+// Order returns a view of bb whose unsuffixed accessors use order; a nil
+// order means big-endian. Wrapping an OrderedByteBuf replaces its order
+// instead of stacking views.
+//
+// When bb implements every optional interface of the buffers in this
+// package (Slicer, RetainedSlicer, RefCounted, TryReader, VarintCodec,
+// AbsoluteAccessor, io.ReaderFrom, io.WriterTo, io.ReaderAt, io.Seeker,
+// io.ByteScanner and io.RuneScanner) the view implements them as well.
+// The set is forwarded all or nothing: if bb lacks any one of them the
+// view is a plain ByteBuf, and the capabilities bb does have are reached
+// through Unwrap.
+func Order(bb ByteBuf, order binary.ByteOrder) OrderedByteBuf {
+	if bb == nil {
+		panic(ErrNilObject)
+	}
+	if order == nil {
+		order = binary.BigEndian
+	}
+	if o, ok := bb.(OrderedByteBuf); ok {
+		bb = o.Unwrap()
+	}
+	return newOrdered(bb, order, isLittleEndian(order))
+}
+
+// ByteOrderOf returns the order used by bb's unsuffixed accessors:
+// Order() for an OrderedByteBuf and big-endian otherwise.
+func ByteOrderOf(bb ByteBuf) binary.ByteOrder {
+	if o, ok := bb.(OrderedByteBuf); ok {
+		return o.Order()
+	}
+	return binary.BigEndian
+}
+
+// orderedCaps is the capability set of the buffers made by this package.
+type orderedCaps interface {
+	ByteBuf
+	Slicer
+	RetainedSlicer
+	RefCounted
+	TryReader
+	VarintCodec
+	AbsoluteAccessor
+	io.ReaderFrom
+	io.WriterTo
+	io.ReaderAt
+	io.Seeker
+	io.ByteScanner
+	io.RuneScanner
+}
+
+func newOrdered(bb ByteBuf, order binary.ByteOrder, le bool) OrderedByteBuf {
+	if c, ok := bb.(orderedCaps); ok {
+		return &orderedCapsByteBuf{orderedCaps: c, order: order, le: le}
+	}
+	return &orderedByteBuf{ByteBuf: bb, order: order, le: le}
+}
+
+// orderedByteBuf orders a buffer without the optional capabilities.
+type orderedByteBuf struct {
+	ByteBuf
+	order binary.ByteOrder
+	le    bool
+}
+
+// orderedCapsByteBuf orders a buffer with all of orderedCaps.
+type orderedCapsByteBuf struct {
+	orderedCaps
+	order binary.ByteOrder
+	le    bool
+}
+
+var (
+	_ OrderedByteBuf = (*orderedByteBuf)(nil)
+	_ fmt.Formatter  = (*orderedByteBuf)(nil)
+	_ slog.LogValuer = (*orderedByteBuf)(nil)
+	_ OrderedByteBuf = (*orderedCapsByteBuf)(nil)
+	_ orderedCaps    = (*orderedCapsByteBuf)(nil)
+	_ fmt.Formatter  = (*orderedCapsByteBuf)(nil)
+	_ slog.LogValuer = (*orderedCapsByteBuf)(nil)
+)
+
+func (o *orderedByteBuf) Order() binary.ByteOrder { return o.order }
+func (o *orderedByteBuf) Unwrap() ByteBuf         { return o.ByteBuf }
+
+// wrap applies o's order to a buffer derived from the wrapped one.
+func (o *orderedByteBuf) wrap(bb ByteBuf) ByteBuf { return newOrdered(bb, o.order, o.le) }
+
+func (o *orderedByteBuf) Format(f fmt.State, verb rune) { formatByteBuf(f, verb, o.ByteBuf) }
+func (o *orderedByteBuf) LogValue() slog.Value          { return logValue(o.ByteBuf) }
+
+func (o *orderedCapsByteBuf) Order() binary.ByteOrder { return o.order }
+func (o *orderedCapsByteBuf) Unwrap() ByteBuf         { return o.orderedCaps }
+
+func (o *orderedCapsByteBuf) wrap(bb ByteBuf) ByteBuf { return newOrdered(bb, o.order, o.le) }
+
+func (o *orderedCapsByteBuf) Format(f fmt.State, verb rune) { formatByteBuf(f, verb, o.orderedCaps) }
+func (o *orderedCapsByteBuf) LogValue() slog.Value          { return logValue(o.orderedCaps) }
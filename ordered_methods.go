@@ -0,0 +1,803 @@
+// Code generated by orderedgen. DO NOT EDIT.
+
+package buf
+
+import "io"
+
+func (o *orderedByteBuf) MarkReaderIndex() ByteBuf {
+	o.ByteBuf.MarkReaderIndex()
+	return o
+}
+
+func (o *orderedByteBuf) ResetReaderIndex() ByteBuf {
+	o.ByteBuf.ResetReaderIndex()
+	return o
+}
+
+func (o *orderedByteBuf) MarkWriterIndex() ByteBuf {
+	o.ByteBuf.MarkWriterIndex()
+	return o
+}
+
+func (o *orderedByteBuf) ResetWriterIndex() ByteBuf {
+	o.ByteBuf.ResetWriterIndex()
+	return o
+}
+
+func (o *orderedByteBuf) Reset() ByteBuf {
+	o.ByteBuf.Reset()
+	return o
+}
+
+func (o *orderedByteBuf) Compact() ByteBuf {
+	o.ByteBuf.Compact()
+	return o
+}
+
+func (o *orderedByteBuf) Grow(v int) ByteBuf {
+	o.ByteBuf.Grow(v)
+	return o
+}
+
+func (o *orderedByteBuf) EnsureCapacity(n int) ByteBuf {
+	o.ByteBuf.EnsureCapacity(n)
+	return o
+}
+
+func (o *orderedByteBuf) Skip(v int) ByteBuf {
+	o.ByteBuf.Skip(v)
+	return o
+}
+
+func (o *orderedByteBuf) AppendByte(c byte) ByteBuf {
+	o.ByteBuf.AppendByte(c)
+	return o
+}
+
+func (o *orderedByteBuf) WriteBytes(bs []byte) ByteBuf {
+	o.ByteBuf.WriteBytes(bs)
+	return o
+}
+
+func (o *orderedByteBuf) WriteString(s string) ByteBuf {
+	o.ByteBuf.WriteString(s)
+	return o
+}
+
+func (o *orderedByteBuf) WriteByteBuf(buf ByteBuf) ByteBuf {
+	o.ByteBuf.WriteByteBuf(buf)
+	return o
+}
+
+func (o *orderedByteBuf) WriteReader(reader io.Reader) ByteBuf {
+	o.ByteBuf.WriteReader(reader)
+	return o
+}
+
+func (o *orderedByteBuf) ReadWriter(writer io.Writer) ByteBuf {
+	o.ByteBuf.ReadWriter(writer)
+	return o
+}
+
+func (o *orderedByteBuf) WriteInt16LE(v int16) ByteBuf {
+	o.ByteBuf.WriteInt16LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) WriteInt32LE(v int32) ByteBuf {
+	o.ByteBuf.WriteInt32LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) WriteInt64LE(v int64) ByteBuf {
+	o.ByteBuf.WriteInt64LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) WriteUInt16LE(v uint16) ByteBuf {
+	o.ByteBuf.WriteUInt16LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) WriteUInt32LE(v uint32) ByteBuf {
+	o.ByteBuf.WriteUInt32LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) WriteUInt64LE(v uint64) ByteBuf {
+	o.ByteBuf.WriteUInt64LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) WriteFloat32LE(v float32) ByteBuf {
+	o.ByteBuf.WriteFloat32LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) WriteFloat64LE(v float64) ByteBuf {
+	o.ByteBuf.WriteFloat64LE(v)
+	return o
+}
+
+func (o *orderedByteBuf) Clone() ByteBuf {
+	return o.wrap(o.ByteBuf.Clone())
+}
+
+func (o *orderedByteBuf) ReadByteBuf(n int) ByteBuf {
+	return o.wrap(o.ByteBuf.ReadByteBuf(n))
+}
+
+func (o *orderedByteBuf) WriteInt16(v int16) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteInt16LE(v)
+	} else {
+		o.ByteBuf.WriteInt16(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) WriteInt32(v int32) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteInt32LE(v)
+	} else {
+		o.ByteBuf.WriteInt32(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) WriteInt64(v int64) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteInt64LE(v)
+	} else {
+		o.ByteBuf.WriteInt64(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) WriteUInt16(v uint16) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteUInt16LE(v)
+	} else {
+		o.ByteBuf.WriteUInt16(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) WriteUInt32(v uint32) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteUInt32LE(v)
+	} else {
+		o.ByteBuf.WriteUInt32(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) WriteUInt64(v uint64) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteUInt64LE(v)
+	} else {
+		o.ByteBuf.WriteUInt64(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) WriteFloat32(v float32) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteFloat32LE(v)
+	} else {
+		o.ByteBuf.WriteFloat32(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) WriteFloat64(v float64) ByteBuf {
+	if o.le {
+		o.ByteBuf.WriteFloat64LE(v)
+	} else {
+		o.ByteBuf.WriteFloat64(v)
+	}
+	return o
+}
+
+func (o *orderedByteBuf) ReadInt16() int16 {
+	if o.le {
+		return o.ByteBuf.ReadInt16LE()
+	}
+	return o.ByteBuf.ReadInt16()
+}
+
+func (o *orderedByteBuf) ReadInt32() int32 {
+	if o.le {
+		return o.ByteBuf.ReadInt32LE()
+	}
+	return o.ByteBuf.ReadInt32()
+}
+
+func (o *orderedByteBuf) ReadInt64() int64 {
+	if o.le {
+		return o.ByteBuf.ReadInt64LE()
+	}
+	return o.ByteBuf.ReadInt64()
+}
+
+func (o *orderedByteBuf) ReadUInt16() uint16 {
+	if o.le {
+		return o.ByteBuf.ReadUInt16LE()
+	}
+	return o.ByteBuf.ReadUInt16()
+}
+
+func (o *orderedByteBuf) ReadUInt32() uint32 {
+	if o.le {
+		return o.ByteBuf.ReadUInt32LE()
+	}
+	return o.ByteBuf.ReadUInt32()
+}
+
+func (o *orderedByteBuf) ReadUInt64() uint64 {
+	if o.le {
+		return o.ByteBuf.ReadUInt64LE()
+	}
+	return o.ByteBuf.ReadUInt64()
+}
+
+func (o *orderedByteBuf) ReadFloat32() float32 {
+	if o.le {
+		return o.ByteBuf.ReadFloat32LE()
+	}
+	return o.ByteBuf.ReadFloat32()
+}
+
+func (o *orderedByteBuf) ReadFloat64() float64 {
+	if o.le {
+		return o.ByteBuf.ReadFloat64LE()
+	}
+	return o.ByteBuf.ReadFloat64()
+}
+
+func (o *orderedCapsByteBuf) MarkReaderIndex() ByteBuf {
+	o.orderedCaps.MarkReaderIndex()
+	return o
+}
+
+func (o *orderedCapsByteBuf) ResetReaderIndex() ByteBuf {
+	o.orderedCaps.ResetReaderIndex()
+	return o
+}
+
+func (o *orderedCapsByteBuf) MarkWriterIndex() ByteBuf {
+	o.orderedCaps.MarkWriterIndex()
+	return o
+}
+
+func (o *orderedCapsByteBuf) ResetWriterIndex() ByteBuf {
+	o.orderedCaps.ResetWriterIndex()
+	return o
+}
+
+func (o *orderedCapsByteBuf) Reset() ByteBuf {
+	o.orderedCaps.Reset()
+	return o
+}
+
+func (o *orderedCapsByteBuf) Compact() ByteBuf {
+	o.orderedCaps.Compact()
+	return o
+}
+
+func (o *orderedCapsByteBuf) Grow(v int) ByteBuf {
+	o.orderedCaps.Grow(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) EnsureCapacity(n int) ByteBuf {
+	o.orderedCaps.EnsureCapacity(n)
+	return o
+}
+
+func (o *orderedCapsByteBuf) Skip(v int) ByteBuf {
+	o.orderedCaps.Skip(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) AppendByte(c byte) ByteBuf {
+	o.orderedCaps.AppendByte(c)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteBytes(bs []byte) ByteBuf {
+	o.orderedCaps.WriteBytes(bs)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteString(s string) ByteBuf {
+	o.orderedCaps.WriteString(s)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteByteBuf(buf ByteBuf) ByteBuf {
+	o.orderedCaps.WriteByteBuf(buf)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteReader(reader io.Reader) ByteBuf {
+	o.orderedCaps.WriteReader(reader)
+	return o
+}
+
+func (o *orderedCapsByteBuf) ReadWriter(writer io.Writer) ByteBuf {
+	o.orderedCaps.ReadWriter(writer)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteInt16LE(v int16) ByteBuf {
+	o.orderedCaps.WriteInt16LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteInt32LE(v int32) ByteBuf {
+	o.orderedCaps.WriteInt32LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteInt64LE(v int64) ByteBuf {
+	o.orderedCaps.WriteInt64LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteUInt16LE(v uint16) ByteBuf {
+	o.orderedCaps.WriteUInt16LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteUInt32LE(v uint32) ByteBuf {
+	o.orderedCaps.WriteUInt32LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteUInt64LE(v uint64) ByteBuf {
+	o.orderedCaps.WriteUInt64LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteFloat32LE(v float32) ByteBuf {
+	o.orderedCaps.WriteFloat32LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteFloat64LE(v float64) ByteBuf {
+	o.orderedCaps.WriteFloat64LE(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) Clone() ByteBuf {
+	return o.wrap(o.orderedCaps.Clone())
+}
+
+func (o *orderedCapsByteBuf) ReadByteBuf(n int) ByteBuf {
+	return o.wrap(o.orderedCaps.ReadByteBuf(n))
+}
+
+func (o *orderedCapsByteBuf) WriteInt16(v int16) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteInt16LE(v)
+	} else {
+		o.orderedCaps.WriteInt16(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteInt32(v int32) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteInt32LE(v)
+	} else {
+		o.orderedCaps.WriteInt32(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteInt64(v int64) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteInt64LE(v)
+	} else {
+		o.orderedCaps.WriteInt64(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteUInt16(v uint16) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteUInt16LE(v)
+	} else {
+		o.orderedCaps.WriteUInt16(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteUInt32(v uint32) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteUInt32LE(v)
+	} else {
+		o.orderedCaps.WriteUInt32(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteUInt64(v uint64) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteUInt64LE(v)
+	} else {
+		o.orderedCaps.WriteUInt64(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteFloat32(v float32) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteFloat32LE(v)
+	} else {
+		o.orderedCaps.WriteFloat32(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteFloat64(v float64) ByteBuf {
+	if o.le {
+		o.orderedCaps.WriteFloat64LE(v)
+	} else {
+		o.orderedCaps.WriteFloat64(v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) ReadInt16() int16 {
+	if o.le {
+		return o.orderedCaps.ReadInt16LE()
+	}
+	return o.orderedCaps.ReadInt16()
+}
+
+func (o *orderedCapsByteBuf) ReadInt32() int32 {
+	if o.le {
+		return o.orderedCaps.ReadInt32LE()
+	}
+	return o.orderedCaps.ReadInt32()
+}
+
+func (o *orderedCapsByteBuf) ReadInt64() int64 {
+	if o.le {
+		return o.orderedCaps.ReadInt64LE()
+	}
+	return o.orderedCaps.ReadInt64()
+}
+
+func (o *orderedCapsByteBuf) ReadUInt16() uint16 {
+	if o.le {
+		return o.orderedCaps.ReadUInt16LE()
+	}
+	return o.orderedCaps.ReadUInt16()
+}
+
+func (o *orderedCapsByteBuf) ReadUInt32() uint32 {
+	if o.le {
+		return o.orderedCaps.ReadUInt32LE()
+	}
+	return o.orderedCaps.ReadUInt32()
+}
+
+func (o *orderedCapsByteBuf) ReadUInt64() uint64 {
+	if o.le {
+		return o.orderedCaps.ReadUInt64LE()
+	}
+	return o.orderedCaps.ReadUInt64()
+}
+
+func (o *orderedCapsByteBuf) ReadFloat32() float32 {
+	if o.le {
+		return o.orderedCaps.ReadFloat32LE()
+	}
+	return o.orderedCaps.ReadFloat32()
+}
+
+func (o *orderedCapsByteBuf) ReadFloat64() float64 {
+	if o.le {
+		return o.orderedCaps.ReadFloat64LE()
+	}
+	return o.orderedCaps.ReadFloat64()
+}
+
+func (o *orderedCapsByteBuf) Retain() ByteBuf {
+	o.orderedCaps.Retain()
+	return o
+}
+
+func (o *orderedCapsByteBuf) GetBytes(idx int, dst []byte) ByteBuf {
+	o.orderedCaps.GetBytes(idx, dst)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetByte(idx int, v byte) ByteBuf {
+	o.orderedCaps.SetByte(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetBytes(idx int, src []byte) ByteBuf {
+	o.orderedCaps.SetBytes(idx, src)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteVarUInt32(v uint32) ByteBuf {
+	o.orderedCaps.WriteVarUInt32(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteVarUInt64(v uint64) ByteBuf {
+	o.orderedCaps.WriteVarUInt64(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteVarInt32(v int32) ByteBuf {
+	o.orderedCaps.WriteVarInt32(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) WriteVarInt64(v int64) ByteBuf {
+	o.orderedCaps.WriteVarInt64(v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) Slice(from, length int) ByteBuf {
+	return o.wrap(o.orderedCaps.Slice(from, length))
+}
+
+func (o *orderedCapsByteBuf) Duplicate() ByteBuf {
+	return o.wrap(o.orderedCaps.Duplicate())
+}
+
+func (o *orderedCapsByteBuf) ReadSlice(n int) ByteBuf {
+	return o.wrap(o.orderedCaps.ReadSlice(n))
+}
+
+func (o *orderedCapsByteBuf) RetainedSlice(from, length int) ByteBuf {
+	return o.wrap(o.orderedCaps.RetainedSlice(from, length))
+}
+
+func (o *orderedCapsByteBuf) RetainedDuplicate() ByteBuf {
+	return o.wrap(o.orderedCaps.RetainedDuplicate())
+}
+
+func (o *orderedCapsByteBuf) ReadRetainedSlice(n int) ByteBuf {
+	return o.wrap(o.orderedCaps.ReadRetainedSlice(n))
+}
+
+func (o *orderedCapsByteBuf) TryReadByteBuf(n int) (ByteBuf, error) {
+	bb, err := o.orderedCaps.TryReadByteBuf(n)
+	if err != nil {
+		return nil, err
+	}
+	return o.wrap(bb), nil
+}
+
+func (o *orderedCapsByteBuf) TryReadInt16() (int16, error) {
+	if o.le {
+		return o.orderedCaps.TryReadInt16LE()
+	}
+	return o.orderedCaps.TryReadInt16()
+}
+
+func (o *orderedCapsByteBuf) TryReadInt32() (int32, error) {
+	if o.le {
+		return o.orderedCaps.TryReadInt32LE()
+	}
+	return o.orderedCaps.TryReadInt32()
+}
+
+func (o *orderedCapsByteBuf) TryReadInt64() (int64, error) {
+	if o.le {
+		return o.orderedCaps.TryReadInt64LE()
+	}
+	return o.orderedCaps.TryReadInt64()
+}
+
+func (o *orderedCapsByteBuf) TryReadUInt16() (uint16, error) {
+	if o.le {
+		return o.orderedCaps.TryReadUInt16LE()
+	}
+	return o.orderedCaps.TryReadUInt16()
+}
+
+func (o *orderedCapsByteBuf) TryReadUInt32() (uint32, error) {
+	if o.le {
+		return o.orderedCaps.TryReadUInt32LE()
+	}
+	return o.orderedCaps.TryReadUInt32()
+}
+
+func (o *orderedCapsByteBuf) TryReadUInt64() (uint64, error) {
+	if o.le {
+		return o.orderedCaps.TryReadUInt64LE()
+	}
+	return o.orderedCaps.TryReadUInt64()
+}
+
+func (o *orderedCapsByteBuf) TryReadFloat32() (float32, error) {
+	if o.le {
+		return o.orderedCaps.TryReadFloat32LE()
+	}
+	return o.orderedCaps.TryReadFloat32()
+}
+
+func (o *orderedCapsByteBuf) TryReadFloat64() (float64, error) {
+	if o.le {
+		return o.orderedCaps.TryReadFloat64LE()
+	}
+	return o.orderedCaps.TryReadFloat64()
+}
+
+func (o *orderedCapsByteBuf) GetInt16(idx int) int16 {
+	if o.le {
+		return o.orderedCaps.GetInt16LE(idx)
+	}
+	return o.orderedCaps.GetInt16(idx)
+}
+
+func (o *orderedCapsByteBuf) GetInt32(idx int) int32 {
+	if o.le {
+		return o.orderedCaps.GetInt32LE(idx)
+	}
+	return o.orderedCaps.GetInt32(idx)
+}
+
+func (o *orderedCapsByteBuf) GetInt64(idx int) int64 {
+	if o.le {
+		return o.orderedCaps.GetInt64LE(idx)
+	}
+	return o.orderedCaps.GetInt64(idx)
+}
+
+func (o *orderedCapsByteBuf) GetUInt16(idx int) uint16 {
+	if o.le {
+		return o.orderedCaps.GetUInt16LE(idx)
+	}
+	return o.orderedCaps.GetUInt16(idx)
+}
+
+func (o *orderedCapsByteBuf) GetUInt32(idx int) uint32 {
+	if o.le {
+		return o.orderedCaps.GetUInt32LE(idx)
+	}
+	return o.orderedCaps.GetUInt32(idx)
+}
+
+func (o *orderedCapsByteBuf) GetUInt64(idx int) uint64 {
+	if o.le {
+		return o.orderedCaps.GetUInt64LE(idx)
+	}
+	return o.orderedCaps.GetUInt64(idx)
+}
+
+func (o *orderedCapsByteBuf) GetFloat32(idx int) float32 {
+	if o.le {
+		return o.orderedCaps.GetFloat32LE(idx)
+	}
+	return o.orderedCaps.GetFloat32(idx)
+}
+
+func (o *orderedCapsByteBuf) GetFloat64(idx int) float64 {
+	if o.le {
+		return o.orderedCaps.GetFloat64LE(idx)
+	}
+	return o.orderedCaps.GetFloat64(idx)
+}
+
+func (o *orderedCapsByteBuf) SetInt16LE(idx int, v int16) ByteBuf {
+	o.orderedCaps.SetInt16LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetInt32LE(idx int, v int32) ByteBuf {
+	o.orderedCaps.SetInt32LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetInt64LE(idx int, v int64) ByteBuf {
+	o.orderedCaps.SetInt64LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetUInt16LE(idx int, v uint16) ByteBuf {
+	o.orderedCaps.SetUInt16LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetUInt32LE(idx int, v uint32) ByteBuf {
+	o.orderedCaps.SetUInt32LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetUInt64LE(idx int, v uint64) ByteBuf {
+	o.orderedCaps.SetUInt64LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetFloat32LE(idx int, v float32) ByteBuf {
+	o.orderedCaps.SetFloat32LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetFloat64LE(idx int, v float64) ByteBuf {
+	o.orderedCaps.SetFloat64LE(idx, v)
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetInt16(idx int, v int16) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetInt16LE(idx, v)
+	} else {
+		o.orderedCaps.SetInt16(idx, v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetInt32(idx int, v int32) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetInt32LE(idx, v)
+	} else {
+		o.orderedCaps.SetInt32(idx, v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetInt64(idx int, v int64) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetInt64LE(idx, v)
+	} else {
+		o.orderedCaps.SetInt64(idx, v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetUInt16(idx int, v uint16) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetUInt16LE(idx, v)
+	} else {
+		o.orderedCaps.SetUInt16(idx, v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetUInt32(idx int, v uint32) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetUInt32LE(idx, v)
+	} else {
+		o.orderedCaps.SetUInt32(idx, v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetUInt64(idx int, v uint64) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetUInt64LE(idx, v)
+	} else {
+		o.orderedCaps.SetUInt64(idx, v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetFloat32(idx int, v float32) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetFloat32LE(idx, v)
+	} else {
+		o.orderedCaps.SetFloat32(idx, v)
+	}
+	return o
+}
+
+func (o *orderedCapsByteBuf) SetFloat64(idx int, v float64) ByteBuf {
+	if o.le {
+		o.orderedCaps.SetFloat64LE(idx, v)
+	} else {
+		o.orderedCaps.SetFloat64(idx, v)
+	}
+	return o
+}
@@ -0,0 +1,104 @@
+package buf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Unsuffixed accessors follow the chosen order and share indices with the
+// wrapped buffer.
+func TestOrder_LittleEndian(t *testing.T) {
+	for _, inner := range []ByteBuf{EmptyByteBuf(), NewCompositeByteBuf(bb("x"))} {
+		inner.Skip(inner.ReadableBytes())
+		le := Order(inner, binary.LittleEndian)
+		le.WriteUInt32(0x01020304).WriteInt16(-2).WriteFloat32(1.5).WriteUInt16LE(0x0A0B)
+		assert.Equal(t, []byte{0x04, 0x03, 0x02, 0x01, 0xFE, 0xFF, 0x00, 0x00, 0xC0, 0x3F, 0x0B, 0x0A}, inner.BytesCopy())
+
+		start := inner.ReaderIndex()
+		assert.Equal(t, uint32(0x04030201), inner.(AbsoluteAccessor).GetUInt32(start))
+		assert.Equal(t, uint32(0x01020304), le.(AbsoluteAccessor).GetUInt32(start))
+		assert.Equal(t, uint32(0x01020304), le.ReadUInt32())
+		assert.Equal(t, start+4, inner.ReaderIndex())
+		v, err := le.(TryReader).TryReadInt16()
+		assert.NoError(t, err)
+		assert.Equal(t, int16(-2), v)
+		assert.Equal(t, float32(1.5), le.ReadFloat32())
+		assert.Equal(t, uint16(0x0A0B), le.ReadUInt16LE())
+
+		le.(AbsoluteAccessor).SetUInt16(start, 0x1234)
+		assert.Equal(t, byte(0x34), inner.(AbsoluteAccessor).GetByte(start))
+	}
+}
+
+func TestOrder_ViewsKeepOrder(t *testing.T) {
+	inner := EmptyByteBuf()
+	le := Order(inner, binary.LittleEndian)
+	le.WriteUInt16(1).WriteUInt16(2).WriteUInt16(3).WriteUInt16(4)
+
+	s := le.(Slicer)
+	assert.Equal(t, uint16(1), s.Slice(0, 2).ReadUInt16())
+	assert.Equal(t, uint16(1), s.Duplicate().ReadUInt16())
+	assert.Equal(t, uint16(1), s.ReadSlice(2).ReadUInt16())
+	assert.Equal(t, uint16(2), le.ReadByteBuf(2).ReadUInt16())
+	assert.Equal(t, uint16(3), le.Clone().ReadUInt16())
+	assert.Equal(t, binary.LittleEndian, ByteOrderOf(le.Skip(0)))
+	assert.Equal(t, binary.ByteOrder(binary.BigEndian), ByteOrderOf(inner))
+
+	be := Order(le, binary.BigEndian)
+	assert.Same(t, inner, be.Unwrap())
+	assert.Equal(t, uint16(0x0300), be.ReadUInt16())
+	le.(RefCounted).Retain()
+	assert.Equal(t, int32(2), inner.(RefCounted).RefCnt())
+}
+
+// Package helpers see through the wrapper.
+func TestOrder_Helpers(t *testing.T) {
+	c := NewCompositeByteBuf(bb("ab"), bb("c\n"))
+	le := Order(c, binary.LittleEndian)
+	assert.Equal(t, 3, indexOf(le, 0, []byte("\n")))
+	assert.Equal(t, "61620a", fmt.Sprintf("%x", Order(bb("ab\n"), nil)))
+	assert.Equal(t, CRC32(c, nil), CRC32(le, nil))
+	assert.Equal(t, 2, len(asDefault(c).components))
+}
+
+type plainByteBuf struct{ ByteBuf }
+
+// A view of a buffer without the optional capabilities does not claim them,
+// and keeps its order and capability set through chained calls.
+func TestOrder_Capabilities(t *testing.T) {
+	o := Order(plainByteBuf{EmptyByteBuf()}, binary.LittleEndian)
+	o.WriteUInt32(7)
+	assert.Equal(t, []byte{7, 0, 0, 0}, o.BytesCopy())
+	assert.Equal(t, uint32(7), o.ReadUInt32())
+	for _, v := range []ByteBuf{o, o.Skip(0)} {
+		_, isSlicer := v.(Slicer)
+		_, isVarint := v.(VarintCodec)
+		_, isSeeker := v.(io.Seeker)
+		assert.False(t, isSlicer || isVarint || isSeeker)
+		assert.Equal(t, binary.LittleEndian, ByteOrderOf(v))
+	}
+
+	// Capabilities are forwarded all or nothing: a buffer missing one gets
+	// a plain view, and the ones it has are reached through Unwrap.
+	partial := Order(struct {
+		ByteBuf
+		io.ReaderAt
+		RefCounted
+	}{EmptyByteBuf(), nil, nil}, binary.LittleEndian)
+	_, isReaderAt := partial.(io.ReaderAt)
+	_, isRefCounted := partial.(RefCounted)
+	assert.False(t, isReaderAt || isRefCounted)
+	_, isReaderAt = partial.Unwrap().(io.ReaderAt)
+	assert.True(t, isReaderAt)
+
+	full := Order(EmptyByteBuf(), binary.LittleEndian)
+	for _, v := range []ByteBuf{full, full.Skip(0), full.Clone(), full.(VarintCodec).WriteVarUInt32(1)} {
+		_, isCaps := v.(orderedCaps)
+		assert.True(t, isCaps)
+		assert.Equal(t, binary.LittleEndian, ByteOrderOf(v))
+	}
+}
@@ -15,6 +15,8 @@ func init() {
 		pools[i] = sync.Pool{
 			New: func() any {
 				b := newDefaultByteBuf()
+				b.statShard = randomPoolShard()
+				poolStats[b.statShard].misses[classIdx].Add(1)
 				b.buf = make([]byte, size)
 				b.poolIdx = classIdx
 				return b
@@ -38,7 +40,16 @@ func poolClassIndex(minCap int) int {
 // refcount 1, zeroed indices, and an unspecified readable content. Buffers
 // obtained via AcquireByteBuf must be returned with ReleaseByteBuf when the
 // caller is done.
+//
+// PoolStats reports how the pool behind both functions is used.
 func AcquireByteBuf(minCap int) ByteBuf {
+	return acquireByteBuf(minCap, 1)
+}
+
+// acquireByteBuf implements AcquireByteBuf for the exported entry points.
+// skip is the number of frames above acquireByteBuf's caller at which the
+// code acquiring the buffer sits, for the leak detector.
+func acquireByteBuf(minCap, skip int) *DefaultByteBuf {
 	if minCap < 0 {
 		panic(ErrInsufficientSize)
 	}
@@ -47,51 +58,152 @@ func AcquireByteBuf(minCap int) ByteBuf {
 	}
 	idx := poolClassIndex(minCap)
 	if idx < 0 {
+		sh := &poolStats[randomPoolShard()]
+		sh.oversize.Add(1)
+		sh.oversizeBytes.Add(uint64(minCap))
 		b := newDefaultByteBuf()
 		b.buf = make([]byte, minCap)
+		b.sideOf().oversize = minCap
+		trackLeak(b, skip+1)
 		return b
 	}
 	b := pools[idx].Get().(*DefaultByteBuf)
+	poolStats[b.statShard].acquires[idx].Add(1)
 	b.refcnt.Store(1)
 	b.readerIndex = 0
 	b.writerIndex = 0
 	b.prevReaderIndex = 0
 	b.prevWriterIndex = 0
+	b.lastRune = 0
 	b.poolIdx = int32(idx)
 	// Replace the backing array when it no longer matches the class size,
 	// which can happen if the pooled buffer's buf was detached by a grow.
 	if cap(b.buf) != poolClasses[idx] {
 		b.buf = make([]byte, poolClasses[idx])
 	}
+	trackLeak(b, skip+1)
+	return b
+}
+
+// AcquireManagedByteBuf is AcquireByteBuf for a buffer whose lifetime
+// follows its reference count: see Managed. The caller drops its reference
+// with Release instead of ReleaseByteBuf.
+func AcquireManagedByteBuf(minCap int) *DefaultByteBuf {
+	return Managed(acquireByteBuf(minCap, 1))
+}
+
+// Managed makes the Release that brings b's reference count to zero
+// deallocate b: a pooled backing array goes back to its size class and an
+// unpooled one is dropped. That suits buffers shared between goroutines
+// with Retain, where no single owner knows when to call ReleaseByteBuf.
+//
+// A deallocated buffer has no storage and refcount 0. Reads, Bytes, writes
+// and Retain panic with ErrBufferReleased, the error-returning reads return
+// it, and a further Release panics with ErrRefCountUnderflow. Retained
+// views of b are deallocated with it and report ErrBufferReleased the same
+// way; plain views made by Slice, Duplicate and ReadSlice must not outlive
+// b. For a retained view the root is made managed. Managed returns b.
+//
+// Composites are not covered: a composite made by an Allocator is handed
+// back with Allocator.Release, which waits for its retained views.
+func Managed(b *DefaultByteBuf) *DefaultByteBuf {
+	r := b
+	if root := b.viewRoot(); root != nil {
+		r = root
+	}
+	// Pooled buffers were sampled by AcquireByteBuf already; an unpooled
+	// one takes on a release obligation here.
+	if !r.managed.Swap(true) && r.poolIdx < 0 && r.leakOf() == nil {
+		trackLeak(r, 1)
+	}
 	return b
 }
 
+// deallocate frees a managed buffer at refcount zero. A pooled array is
+// handed to the pool in a fresh DefaultByteBuf, so b itself is never
+// reused and stays detectably released; one already dropped by Close is
+// still counted in PoolStats.
+func (b *DefaultByteBuf) deallocate() {
+	if b.poolIdx >= 0 {
+		fresh := newDefaultByteBuf()
+		fresh.buf = b.buf
+		fresh.poolIdx = b.poolIdx
+		fresh.statShard = b.statShard
+		ReleaseByteBuf(fresh)
+	}
+	b.poolIdx = -1
+	b.releaseOversize()
+	b.Close()
+	// Retained views alias the array just handed back; strip them too so
+	// they report ErrBufferReleased instead of reading recycled memory.
+	sd := b.side.Load()
+	if sd == nil {
+		return
+	}
+	if vs := sd.views.Swap(nil); vs != nil {
+		vs.mu.Lock()
+		for _, w := range vs.list {
+			if v := w.Value(); v != nil {
+				v.Close()
+			}
+		}
+		vs.mu.Unlock()
+	}
+}
+
 // ReleaseByteBuf returns bb to its originating pool only when bb carries
 // a valid poolIdx and owns a class-sized backing array. Views created by
 // Slice, Duplicate, or ReadSlice carry poolIdx == -1 and are never pooled.
 // Buffers whose backing array no longer matches the class size are dropped
-// so the pool caches only predictably-sized arrays. A nil or
-// non-*DefaultByteBuf argument is a no-op.
+// so the pool caches only predictably-sized arrays, and buffers whose array
+// Close dropped are only counted. A nil or
+// non-*DefaultByteBuf argument is a no-op; an OrderedByteBuf releases the
+// buffer it wraps.
+//
+// For a managed buffer (see Managed) ReleaseByteBuf is Release. Retained
+// views are views like any other here and are ignored; drop them with
+// Release. A root that still has retained views holding a reference is not
+// pooled yet: ReleaseByteBuf drops its own reference and makes the root
+// managed, so the Release of the last view returns the array to the pool.
+// Without retained views the reference count is not consulted.
 func ReleaseByteBuf(bb ByteBuf) {
-	if bb == nil {
-		return
+	if o, ok := bb.(OrderedByteBuf); ok {
+		bb = o.Unwrap()
 	}
 	b, ok := bb.(*DefaultByteBuf)
-	if !ok {
+	if !ok || b == nil {
+		return
+	}
+	if vs := b.viewsOf(); vs != nil && b.viewRoot() == nil && !b.managed.Load() && vs.refs.Load() > 0 {
+		b.managed.Store(true)
+	}
+	if b.managed.Load() {
+		b.Release()
 		return
 	}
+	closeLeak(b)
+	b.releaseOversize()
 	idx := b.poolIdx
 	if idx < 0 || int(idx) >= len(poolClasses) {
 		return
 	}
-	if b.buf == nil || cap(b.buf) != poolClasses[idx] {
+	if b.buf == nil {
+		poolStats[b.statShard].closed[idx].Add(1)
+		b.poolIdx = -1
+		return
+	}
+	if cap(b.buf) != poolClasses[idx] {
+		poolStats[b.statShard].dropped[idx].Add(1)
 		b.poolIdx = -1
 		return
 	}
+	poolStats[b.statShard].releases[idx].Add(1)
 	b.readerIndex = 0
 	b.writerIndex = 0
 	b.prevReaderIndex = 0
 	b.prevWriterIndex = 0
+	b.lastRune = 0
+	b.side.Store(nil)
 	b.refcnt.Store(0)
 	pools[idx].Put(b)
 }
@@ -0,0 +1,150 @@
+package buf
+
+import (
+	"expvar"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// poolStatShards spreads the pool counters over several cache lines so
+// concurrent Acquire/Release calls rarely contend on the same word. Must be
+// a power of two.
+const poolStatShards = 16
+
+type poolCounters struct {
+	acquires [len(poolClasses)]atomic.Uint64
+	releases [len(poolClasses)]atomic.Uint64
+	misses   [len(poolClasses)]atomic.Uint64
+	dropped  [len(poolClasses)]atomic.Uint64
+	closed   [len(poolClasses)]atomic.Uint64
+	oversize atomic.Uint64
+	// oversizeBytes sums the minCap of oversize requests; the released
+	// counters cover those handed back with ReleaseByteBuf or a final
+	// Release.
+	oversizeBytes         atomic.Uint64
+	oversizeReleases      atomic.Uint64
+	oversizeReleasedBytes atomic.Uint64
+	_                     [64]byte // keeps neighbouring shards off this cache line
+}
+
+var poolStats [poolStatShards]poolCounters
+
+// randomPoolShard picks a shard for a new pooled buffer or a call without
+// one. The runtime generator behind rand.Uint32 is per-thread, so the pick
+// does not contend. Pooled buffers keep their shard, which spares the hot
+// path the random draw; since sync.Pool caches per P, a buffer's shard is
+// mostly touched from one P.
+func randomPoolShard() uint8 {
+	return uint8(rand.Uint32() & (poolStatShards - 1))
+}
+
+// releaseOversize counts b as released when it is an oversize buffer from
+// AcquireByteBuf that was not counted yet.
+func (b *DefaultByteBuf) releaseOversize() {
+	sd := b.side.Load()
+	if sd == nil || sd.oversize == 0 {
+		return
+	}
+	sh := &poolStats[randomPoolShard()]
+	sh.oversizeReleases.Add(1)
+	sh.oversizeReleasedBytes.Add(uint64(sd.oversize))
+	sd.oversize = 0
+}
+
+// PoolClassStats holds the counters of one pool size class.
+type PoolClassStats struct {
+	// Size is the backing-array size of the class.
+	Size int
+	// Acquires counts AcquireByteBuf calls served by the class.
+	Acquires uint64
+	// Releases counts buffers ReleaseByteBuf returned to the class.
+	Releases uint64
+	// Misses counts acquires the pool could not serve from its cache and
+	// had to allocate for.
+	Misses uint64
+	// Dropped counts buffers ReleaseByteBuf discarded because their
+	// backing array had grown past the class size.
+	Dropped uint64
+	// Closed counts buffers ReleaseByteBuf discarded because Close had
+	// already dropped their backing array.
+	Closed uint64
+	// Outstanding is the number of buffers acquired and not yet released,
+	// dropped or closed.
+	Outstanding uint64
+	// OutstandingBytes is Outstanding times Size.
+	OutstandingBytes uint64
+}
+
+// PoolStatsSnapshot is a point-in-time view of the buffer pool counters.
+type PoolStatsSnapshot struct {
+	// Classes holds one entry per size class, in ascending size order.
+	Classes []PoolClassStats
+	// Oversize counts AcquireByteBuf calls larger than the biggest class,
+	// which bypass the pool.
+	Oversize uint64
+	// OversizeBytes sums the capacity requested by those calls.
+	OversizeBytes uint64
+	// OversizeOutstanding is the number of oversize buffers not yet
+	// released, and OversizeOutstandingBytes their requested capacity.
+	OversizeOutstanding      uint64
+	OversizeOutstandingBytes uint64
+	// OutstandingBytes sums OutstandingBytes over all classes and
+	// OversizeOutstandingBytes.
+	OutstandingBytes uint64
+}
+
+// PoolStats returns the counters of the pool behind AcquireByteBuf and
+// ReleaseByteBuf, accumulated since the process started. Counters are read
+// one by one without stopping concurrent callers, so a snapshot taken under
+// load is consistent only to within the calls in flight. Releasing a
+// pooled buffer twice skews Outstanding.
+func PoolStats() PoolStatsSnapshot {
+	var s PoolStatsSnapshot
+	s.Classes = make([]PoolClassStats, len(poolClasses))
+	// Releases are summed before acquires, so every release seen has its
+	// acquire seen too and Outstanding cannot go negative.
+	var oversizeReleases, oversizeReleasedBytes uint64
+	for i := range poolStats {
+		sh := &poolStats[i]
+		for c := range poolClasses {
+			s.Classes[c].Releases += sh.releases[c].Load()
+			s.Classes[c].Dropped += sh.dropped[c].Load()
+			s.Classes[c].Closed += sh.closed[c].Load()
+		}
+		oversizeReleases += sh.oversizeReleases.Load()
+		oversizeReleasedBytes += sh.oversizeReleasedBytes.Load()
+	}
+	for i := range poolStats {
+		sh := &poolStats[i]
+		for c := range poolClasses {
+			s.Classes[c].Acquires += sh.acquires[c].Load()
+			s.Classes[c].Misses += sh.misses[c].Load()
+		}
+		s.Oversize += sh.oversize.Load()
+		s.OversizeBytes += sh.oversizeBytes.Load()
+	}
+	for c, size := range poolClasses {
+		cs := &s.Classes[c]
+		cs.Size = size
+		if done := cs.Releases + cs.Dropped + cs.Closed; cs.Acquires > done {
+			cs.Outstanding = cs.Acquires - done
+		}
+		cs.OutstandingBytes = cs.Outstanding * uint64(size)
+		s.OutstandingBytes += cs.OutstandingBytes
+	}
+	if s.Oversize > oversizeReleases {
+		s.OversizeOutstanding = s.Oversize - oversizeReleases
+	}
+	if s.OversizeBytes > oversizeReleasedBytes {
+		s.OversizeOutstandingBytes = s.OversizeBytes - oversizeReleasedBytes
+	}
+	s.OutstandingBytes += s.OversizeOutstandingBytes
+	return s
+}
+
+// PublishPoolStats publishes PoolStats under name in expvar, so it is
+// served on /debug/vars. Like expvar.Publish it panics when name is
+// already in use.
+func PublishPoolStats(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return PoolStats() }))
+}
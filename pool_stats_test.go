@@ -0,0 +1,112 @@
+package buf
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolStats_Counters(t *testing.T) {
+	const class = 2 // 1 KiB
+	before := PoolStats()
+
+	a := AcquireByteBuf(1000)
+	b := AcquireByteBuf(1 << 10)
+	grown := AcquireByteBuf(600)
+	grown.WriteBytes(make([]byte, 4<<10))
+	big := AcquireByteBuf(1 << 20)
+
+	mid := PoolStats()
+	assert.Equal(t, uint64(3), mid.Classes[class].Acquires-before.Classes[class].Acquires)
+	assert.Equal(t, before.Classes[class].Outstanding+3, mid.Classes[class].Outstanding)
+	assert.Equal(t, mid.Classes[class].Outstanding*1024, mid.Classes[class].OutstandingBytes)
+	assert.Equal(t, uint64(1), mid.Oversize-before.Oversize)
+	assert.Equal(t, uint64(1<<20), mid.OversizeBytes-before.OversizeBytes)
+	assert.Equal(t, before.OversizeOutstanding+1, mid.OversizeOutstanding)
+	assert.Equal(t, before.OversizeOutstandingBytes+1<<20, mid.OversizeOutstandingBytes)
+	assert.GreaterOrEqual(t, mid.OutstandingBytes, before.OutstandingBytes+1<<20+3*1024)
+	assert.LessOrEqual(t, mid.Classes[class].Misses, mid.Classes[class].Acquires)
+
+	ReleaseByteBuf(a)
+	ReleaseByteBuf(b)
+	ReleaseByteBuf(grown)
+	ReleaseByteBuf(big)
+
+	after := PoolStats()
+	assert.Equal(t, uint64(2), after.Classes[class].Releases-before.Classes[class].Releases)
+	assert.Equal(t, uint64(1), after.Classes[class].Dropped-before.Classes[class].Dropped)
+	assert.Equal(t, before.Classes[class].Outstanding, after.Classes[class].Outstanding)
+	assert.Equal(t, before.OutstandingBytes, after.OutstandingBytes)
+	assert.Equal(t, before.OversizeOutstanding, after.OversizeOutstanding)
+
+	assert.Len(t, after.Classes, len(poolClasses))
+	for i, cs := range after.Classes {
+		assert.Equal(t, poolClasses[i], cs.Size)
+	}
+}
+
+// A buffer closed before its release is counted as closed, not dropped,
+// and no longer outstanding.
+func TestPoolStats_Closed(t *testing.T) {
+	const class = 3 // 4 KiB
+	before := PoolStats().Classes[class]
+	b := AcquireByteBuf(4 << 10)
+	assert.NoError(t, b.Close())
+	ReleaseByteBuf(b)
+	m := AcquireManagedByteBuf(4 << 10)
+	assert.NoError(t, m.Close())
+	m.Release()
+
+	after := PoolStats().Classes[class]
+	assert.Equal(t, uint64(2), after.Closed-before.Closed)
+	assert.Equal(t, before.Dropped, after.Dropped)
+	assert.Equal(t, before.Releases, after.Releases)
+	assert.Equal(t, before.Outstanding, after.Outstanding)
+}
+
+func TestPoolStats_Misses(t *testing.T) {
+	const class = 6 // 256 KiB
+	before := PoolStats().Classes[class]
+	// Holding every buffer forces the pool to allocate at least the ones
+	// beyond what it had cached.
+	var held []ByteBuf
+	for range 4 {
+		held = append(held, AcquireByteBuf(200<<10))
+	}
+	after := PoolStats().Classes[class]
+	assert.Equal(t, uint64(4), after.Acquires-before.Acquires)
+	assert.NotZero(t, after.Misses)
+	for _, b := range held {
+		ReleaseByteBuf(b)
+	}
+}
+
+// Oversize buffers count as outstanding until released, once, either way.
+func TestPoolStats_OversizeOutstanding(t *testing.T) {
+	before := PoolStats()
+	plain := AcquireByteBuf(1 << 20)
+	managed := AcquireManagedByteBuf(2 << 20)
+	mid := PoolStats()
+	assert.Equal(t, before.OversizeOutstanding+2, mid.OversizeOutstanding)
+	assert.Equal(t, before.OversizeOutstandingBytes+3<<20, mid.OversizeOutstandingBytes)
+
+	ReleaseByteBuf(plain)
+	ReleaseByteBuf(plain)
+	assert.True(t, managed.Release())
+	after := PoolStats()
+	assert.Equal(t, before.OversizeOutstanding, after.OversizeOutstanding)
+	assert.Equal(t, before.OversizeOutstandingBytes, after.OversizeOutstandingBytes)
+}
+
+func TestPublishPoolStats(t *testing.T) {
+	name := fmt.Sprintf("bytebuf_pool_test_%d", rand.Uint64())
+	PublishPoolStats(name)
+	var got PoolStatsSnapshot
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get(name).String()), &got))
+	assert.Len(t, got.Classes, len(poolClasses))
+	assert.Panics(t, func() { PublishPoolStats(name) })
+}
@@ -0,0 +1,126 @@
+package buf
+
+import (
+	"errors"
+	"io"
+)
+
+// ReplayingDecodeFunc decodes one message from in with straight-line reads
+// such as ReadUInt16 and ReadBytes. Running out of input, either by a read
+// panicking with ErrInsufficientSize or by returning it, makes the
+// decoder rewind to the last checkpoint and wait for more bytes. d gives
+// access to Checkpoint and State.
+type ReplayingDecodeFunc[T any] func(d *ReplayingDecoder[T], in ByteBuf) (T, error)
+
+// ReplayingDecoder runs a ReplayingDecodeFunc against cumulated input and
+// replays it from the last checkpoint whenever the input runs out
+// mid-message, so the function never has to check how much is readable.
+// Incoming ByteBufs are cumulated into a CompositeByteBuf without copying
+// and fully consumed components are dropped with Compact after every
+// decode attempt.
+//
+// Each attempt starts at the checkpoint, which is the start of the current
+// message unless the function called Checkpoint. For long messages the
+// function can checkpoint after each completed part and record in the
+// state where to resume, so a replay does not redo the finished parts.
+//
+// Added buffers are aliased, so callers must not overwrite them while any
+// slice read from the cumulation is still in use.
+//
+// ReplayingDecoder is NOT goroutine-safe.
+type ReplayingDecoder[T any] struct {
+	decode     ReplayingDecodeFunc[T]
+	cumulation CompositeByteBuf
+	alloc      Allocator // source of cumulation, nil for NewCompositeByteBuf
+	state      int
+	checkpoint int
+}
+
+// NewReplayingDecoder returns a decoder that runs decode in state 0 and
+// draws its cumulation from alloc. A nil alloc allocates it directly.
+func NewReplayingDecoder[T any](decode ReplayingDecodeFunc[T], alloc Allocator) *ReplayingDecoder[T] {
+	if decode == nil {
+		panic(ErrNilObject)
+	}
+	return &ReplayingDecoder[T]{decode: decode, cumulation: compositeFrom(alloc), alloc: alloc}
+}
+
+// Add appends the readable region of in to the cumulation and marks it as
+// consumed on in.
+func (d *ReplayingDecoder[T]) Add(in ByteBuf) {
+	cumulate(d.cumulation, in)
+}
+
+// Close releases the cumulation to the allocator it was drawn from.
+// Neither the decoder nor slices read from the cumulation may be used
+// afterwards.
+func (d *ReplayingDecoder[T]) Close() error {
+	releaseComposite(d.alloc, d.cumulation)
+	d.state, d.checkpoint = 0, 0
+	return nil
+}
+
+// Buffered returns the number of cumulated bytes not yet consumed.
+func (d *ReplayingDecoder[T]) Buffered() int {
+	return d.cumulation.ReadableBytes()
+}
+
+// State returns the state set by the last Checkpoint.
+func (d *ReplayingDecoder[T]) State() int { return d.state }
+
+// Checkpoint commits the bytes read so far, so a replay resumes from the
+// current reader index, and sets the state the next attempt sees. It is
+// meant to be called from the decode function.
+func (d *ReplayingDecoder[T]) Checkpoint(state int) {
+	d.state = state
+	d.checkpoint = d.cumulation.ReaderIndex()
+}
+
+// Decode adds in and returns every message that is now complete. A non-nil
+// error is returned together with the messages decoded before it.
+func (d *ReplayingDecoder[T]) Decode(in ByteBuf) ([]T, error) {
+	d.Add(in)
+	var msgs []T
+	for {
+		msg, ok, err := d.Next()
+		if err != nil || !ok {
+			return msgs, err
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
+// Next runs the decode function once. ok is false when more input is
+// needed, in which case the cumulation is back at the last checkpoint. An
+// error other than ErrInsufficientSize is returned with the bytes the
+// function consumed before failing dropped. A message decoded without
+// consuming any input yields io.ErrNoProgress.
+func (d *ReplayingDecoder[T]) Next() (msg T, ok bool, err error) {
+	c := d.cumulation
+	defer c.Compact()
+	if c.ReadableBytes() == 0 {
+		return msg, false, nil
+	}
+	start := c.ReaderIndex()
+	d.checkpoint = start
+	var decodeErr error
+	err = Transaction(c, func(in ByteBuf) error {
+		msg, decodeErr = d.decode(d, in)
+		if errors.Is(decodeErr, ErrInsufficientSize) {
+			return decodeErr
+		}
+		return nil
+	})
+	var zero T
+	if err != nil {
+		c.Skip(d.checkpoint - start)
+		return zero, false, nil
+	}
+	if decodeErr != nil {
+		return zero, false, decodeErr
+	}
+	if c.ReaderIndex() == start {
+		return zero, false, io.ErrNoProgress
+	}
+	return msg, true, nil
+}
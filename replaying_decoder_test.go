@@ -0,0 +1,143 @@
+package buf
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type replayMsg struct {
+	Kind uint8
+	Body string
+}
+
+var errBadKind = errors.New("bad kind")
+
+// decodeReplayMsg reads kind(u8) len(u16) body, rejecting kind 0xff.
+func decodeReplayMsg(_ *ReplayingDecoder[replayMsg], in ByteBuf) (replayMsg, error) {
+	kind := in.MustReadByte()
+	if kind == 0xff {
+		return replayMsg{}, errBadKind
+	}
+	n := int(in.ReadUInt16())
+	return replayMsg{Kind: kind, Body: string(in.ReadBytes(n))}, nil
+}
+
+func encodeReplayMsg(kind uint8, body string) ByteBuf {
+	return EmptyByteBuf().AppendByte(kind).WriteUInt16(uint16(len(body))).WriteString(body)
+}
+
+func TestReplayingDecoder_ByteAtATime(t *testing.T) {
+	d := NewReplayingDecoder(decodeReplayMsg, nil)
+	wire := EmptyByteBuf().
+		WriteByteBuf(encodeReplayMsg(1, "hello")).
+		WriteByteBuf(encodeReplayMsg(2, "")).
+		WriteByteBuf(encodeReplayMsg(3, "world!"))
+
+	var got []replayMsg
+	for _, c := range wire.BytesCopy() {
+		msgs, err := d.Decode(bbBytes([]byte{c}))
+		assert.NoError(t, err)
+		got = append(got, msgs...)
+	}
+	assert.Equal(t, []replayMsg{{1, "hello"}, {2, ""}, {3, "world!"}}, got)
+	assert.Equal(t, 0, d.Buffered())
+	assert.Empty(t, asDefault(d.cumulation).components)
+}
+
+func TestReplayingDecoder_PartialKeepsInput(t *testing.T) {
+	d := NewReplayingDecoder(decodeReplayMsg, nil)
+	msgs, err := d.Decode(bb("\x01\x00\x05hel"))
+	assert.NoError(t, err)
+	assert.Empty(t, msgs)
+	assert.Equal(t, 6, d.Buffered())
+
+	msgs, err = d.Decode(bb("lo\x02\x00"))
+	assert.NoError(t, err)
+	assert.Equal(t, []replayMsg{{1, "hello"}}, msgs)
+	assert.Equal(t, 2, d.Buffered())
+	// The first message's components were compacted away.
+	assert.Equal(t, 0, d.cumulation.ReaderIndex())
+}
+
+// Close hands the cumulation, and the tails it drew, back to the
+// allocator.
+func TestReplayingDecoder_Close(t *testing.T) {
+	alloc := NewTrackingAllocator(Pooled)
+	d := NewReplayingDecoder(decodeReplayMsg, alloc)
+	_, err := d.Decode(bb("\x01\x00\x05hel"))
+	assert.NoError(t, err)
+	assert.NotZero(t, alloc.Outstanding())
+	assert.NoError(t, d.Close())
+	assert.Zero(t, alloc.Outstanding())
+
+	assert.NoError(t, NewReplayingDecoder(decodeReplayMsg, nil).Close())
+}
+
+// Checkpoints keep a replay from re-reading the parts already decoded.
+func TestReplayingDecoder_Checkpoint(t *testing.T) {
+	const (
+		stateHeader = iota
+		stateBody
+	)
+	var n int
+	var reads []int
+	decode := func(d *ReplayingDecoder[string], in ByteBuf) (string, error) {
+		reads = append(reads, d.State())
+		if d.State() == stateHeader {
+			n = int(in.ReadUInt16())
+			d.Checkpoint(stateBody)
+		}
+		body := string(in.ReadBytes(n))
+		d.Checkpoint(stateHeader)
+		return body, nil
+	}
+	d := NewReplayingDecoder(decode, nil)
+	msgs, err := d.Decode(bb("\x00\x04ab"))
+	assert.NoError(t, err)
+	assert.Empty(t, msgs)
+	assert.Equal(t, stateBody, d.State())
+	assert.Equal(t, 2, d.Buffered())
+
+	msgs, err = d.Decode(bb("c"))
+	assert.NoError(t, err)
+	assert.Empty(t, msgs)
+
+	msgs, err = d.Decode(bb("d\x00\x01x"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abcd", "x"}, msgs)
+	assert.Equal(t, []int{stateHeader, stateBody, stateBody, stateHeader}, reads)
+	assert.Equal(t, stateHeader, d.State())
+}
+
+func TestReplayingDecoder_Errors(t *testing.T) {
+	d := NewReplayingDecoder(decodeReplayMsg, nil)
+	msgs, err := d.Decode(bb("\x01\x00\x01a\xff\x02\x00\x01b"))
+	assert.ErrorIs(t, err, errBadKind)
+	assert.Equal(t, []replayMsg{{1, "a"}}, msgs)
+
+	// The rejected byte was dropped, so decoding resumes after it.
+	msgs, err = d.Decode(EmptyByteBuf())
+	assert.NoError(t, err)
+	assert.Equal(t, []replayMsg{{2, "b"}}, msgs)
+
+	// A decode function that returns ErrInsufficientSize waits like one
+	// that panics with it.
+	try := NewReplayingDecoder(func(_ *ReplayingDecoder[uint32], in ByteBuf) (uint32, error) {
+		return in.(TryReader).TryReadUInt32()
+	}, nil)
+	msgs32, err := try.Decode(bb("\x00\x00\x01"))
+	assert.NoError(t, err)
+	assert.Empty(t, msgs32)
+	msgs32, err = try.Decode(bb("\x02"))
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{0x102}, msgs32)
+
+	stuck := NewReplayingDecoder(func(*ReplayingDecoder[int], ByteBuf) (int, error) { return 0, nil }, nil)
+	_, err = stuck.Decode(bb("x"))
+	assert.ErrorIs(t, err, io.ErrNoProgress)
+
+	assert.PanicsWithValue(t, ErrNilObject, func() { NewReplayingDecoder[int](nil, nil) })
+}
@@ -0,0 +1,75 @@
+package buf
+
+// checkRange panics with ErrInsufficientSize unless [from, from+length) lies
+// within [0, bb.WriterIndex()).
+func checkRange(bb ByteBuf, from, length int) {
+	if from < 0 || length < 0 || from > bb.WriterIndex()-length {
+		panic(ErrInsufficientSize)
+	}
+}
+
+// segmentCursor walks the bytes of [from, from+length) one contiguous
+// segment at a time without moving indices. Composites yield one segment
+// per component and are walked without locate, so not even the lookup cache
+// is touched; neither buffer type of this package allocates. Other
+// ByteBufs are copied once up front.
+type segmentCursor struct {
+	composite bool
+	comps     []compositeComponent
+	idx       int // next component
+	start     int // absolute start of comps[idx]
+	flat      []byte
+	pos, end  int
+}
+
+// newSegmentCursor returns a cursor over [from, from+length) of bb. The
+// range must already be validated.
+func newSegmentCursor(bb ByteBuf, from, length int) segmentCursor {
+	switch b := bb.(type) {
+	case *DefaultByteBuf:
+		return segmentCursor{flat: b.buf[from : from+length], pos: from, end: from + length}
+	case *defaultCompositeByteBuf:
+		c := segmentCursor{composite: true, comps: b.components, pos: from, end: from + length}
+		for c.idx < len(c.comps) && c.comps[c.idx].endOffset <= from {
+			c.start = c.comps[c.idx].endOffset
+			c.idx++
+		}
+		return c
+	case OrderedByteBuf:
+		return newSegmentCursor(b.Unwrap(), from, length)
+	case AbsoluteAccessor:
+		data := make([]byte, length)
+		b.GetBytes(from, data)
+		return segmentCursor{flat: data, pos: from, end: from + length}
+	}
+	rel := from - bb.ReaderIndex()
+	if rel < 0 {
+		panic(ErrInsufficientSize)
+	}
+	return segmentCursor{flat: bb.BytesCopy()[rel : rel+length], pos: from, end: from + length}
+}
+
+// next returns the next non-empty segment and its absolute start index, or
+// a nil segment once the range is exhausted.
+func (c *segmentCursor) next() (int, []byte) {
+	if c.pos >= c.end {
+		return c.pos, nil
+	}
+	if !c.composite {
+		start, seg := c.pos, c.flat
+		c.pos, c.flat = c.end, nil
+		return start, seg
+	}
+	for c.idx < len(c.comps) {
+		comp := c.comps[c.idx]
+		lo, hi := c.pos, min(c.end, comp.endOffset)
+		seg := comp.data[lo-c.start : hi-c.start]
+		c.start = comp.endOffset
+		c.idx++
+		c.pos = hi
+		if lo < hi {
+			return lo, seg
+		}
+	}
+	return c.pos, nil
+}
@@ -0,0 +1,180 @@
+package buf
+
+import "errors"
+
+// Transaction runs fn on bb and rolls bb back when fn fails. Before fn
+// runs the reader index, writer index and both marks are recorded; when fn
+// returns an error or panics with ErrInsufficientSize they are restored,
+// so bytes fn read become readable again and bytes it appended are
+// dropped. The error is returned; the panic is returned as an error. Any
+// other panic is propagated after the rollback.
+//
+// Transactions nest: an inner rollback restores the inner snapshot and the
+// outer transaction can still roll back further. While a transaction is
+// open, compaction (Compact, and growth of a DefaultByteBuf) keeps the
+// bytes a rollback needs, so fn may freely read, write and mark. Bytes
+// overwritten in place (WriteAt, Set*) are not restored, and fn must not
+// Reset or Close bb.
+//
+// Transaction supports the ByteBufs of this package and panics with
+// ErrUnsupportedOperation for other implementations.
+func Transaction(bb ByteBuf, fn func(ByteBuf) error) (err error) {
+	if bb == nil || fn == nil {
+		panic(ErrNilObject)
+	}
+	tx := transactionalOf(bb)
+	snap := tx.txBegin()
+	defer func() {
+		r := recover()
+		if r != nil || err != nil {
+			tx.txRollback(snap)
+		}
+		tx.txEnd(snap)
+		if r == nil {
+			return
+		}
+		if e, ok := r.(error); ok && errors.Is(e, ErrInsufficientSize) {
+			err = e
+			return
+		}
+		panic(r)
+	}()
+	return fn(bb)
+}
+
+// transactional is implemented by the ByteBufs Transaction can roll back.
+type transactional interface {
+	// txBegin records the indices and pins the bytes from the lowest of
+	// them against compaction.
+	txBegin() txState
+	// txRollback restores the indices recorded by txBegin.
+	txRollback(s txState)
+	// txEnd releases the pin taken by txBegin.
+	txEnd(s txState)
+}
+
+// txState holds indices offset by the discarded count at the time they
+// were taken, so they stay valid across compaction.
+type txState struct {
+	ridx, widx, rmark, wmark int
+	prevPin                  int
+}
+
+func transactionalOf(bb ByteBuf) transactional {
+	switch b := bb.(type) {
+	case *DefaultByteBuf:
+		return b
+	case *defaultCompositeByteBuf:
+		return b
+	case OrderedByteBuf:
+		return transactionalOf(b.Unwrap())
+	}
+	panic(ErrUnsupportedOperation)
+}
+
+// txPinFor returns the pin for a transaction starting at reader index ridx
+// with reader mark rmark, keeping an outer pin that is already lower.
+func txPinFor(pin, discarded, ridx, rmark int) int {
+	if rmark > 0 {
+		ridx = min(ridx, rmark)
+	}
+	if p := discarded + ridx + 1; pin == 0 || p < pin {
+		return p
+	}
+	return pin
+}
+
+// txFloor lowers a compaction offset so the bytes pinned by an open
+// Transaction are kept.
+func (b *DefaultByteBuf) txFloor(offset int) int {
+	if sd := b.side.Load(); sd != nil && sd.txPin > 0 {
+		return min(offset, sd.txPin-1-sd.discarded)
+	}
+	return offset
+}
+
+func (b *DefaultByteBuf) txBegin() txState {
+	sd := b.sideOf()
+	d := sd.discarded
+	s := txState{
+		ridx:    b.readerIndex + d,
+		widx:    b.writerIndex + d,
+		rmark:   b.prevReaderIndex + d,
+		wmark:   b.prevWriterIndex + d,
+		prevPin: sd.txPin,
+	}
+	sd.txPin = txPinFor(sd.txPin, d, b.readerIndex, b.prevReaderIndex)
+	return s
+}
+
+func (b *DefaultByteBuf) txRollback(s txState) {
+	d := b.sideOf().discarded
+	b.readerIndex = s.ridx - d
+	b.writerIndex = s.widx - d
+	b.prevReaderIndex = max(s.rmark-d, 0)
+	b.prevWriterIndex = max(s.wmark-d, 0)
+	b.lastRune = 0
+}
+
+func (b *DefaultByteBuf) txEnd(s txState) { b.sideOf().txPin = s.prevPin }
+
+// txFloor lowers a compaction offset so the bytes pinned by an open
+// Transaction are kept.
+func (c *defaultCompositeByteBuf) txFloor(offset int) int {
+	if c.txPin > 0 {
+		return min(offset, c.txPin-1-c.discarded)
+	}
+	return offset
+}
+
+func (c *defaultCompositeByteBuf) txBegin() txState {
+	d := c.discarded
+	s := txState{
+		ridx:    c.readerIdx + d,
+		widx:    c.writerIdx + d,
+		rmark:   c.prevReaderIdx + d,
+		wmark:   c.prevWriterIdx + d,
+		prevPin: c.txPin,
+	}
+	c.txPin = txPinFor(c.txPin, d, c.readerIdx, c.prevReaderIdx)
+	return s
+}
+
+func (c *defaultCompositeByteBuf) txRollback(s txState) {
+	d := c.discarded
+	if widx := s.widx - d; widx < c.writerIdx {
+		c.truncate(widx)
+	}
+	c.readerIdx = s.ridx - d
+	c.prevReaderIdx = max(s.rmark-d, 0)
+	c.prevWriterIdx = max(s.wmark-d, 0)
+	c.lastHit = 0
+	c.lastRune = 0
+}
+
+func (c *defaultCompositeByteBuf) txEnd(s txState) { c.txPin = s.prevPin }
+
+// truncate drops the bytes at and after absolute index widx, removing the
+// components that start there and trimming the one that straddles it.
+// The writable tail is kept when its component survives.
+func (c *defaultCompositeByteBuf) truncate(widx int) {
+	last := len(c.components) - 1
+	for last >= 0 && c.components[last].endOffset-len(c.components[last].data) >= widx {
+		last--
+	}
+	if c.tail != nil && last < len(c.components)-1 {
+		c.tail = nil
+	}
+	c.components = c.components[:last+1]
+	if last >= 0 {
+		comp := &c.components[last]
+		if cut := comp.endOffset - widx; cut > 0 {
+			comp.data = comp.data[:len(comp.data)-cut]
+			comp.endOffset = widx
+			if c.tail != nil {
+				c.tail.writerIndex -= cut
+			}
+		}
+	}
+	c.writerIdx = widx
+}
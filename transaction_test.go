@@ -0,0 +1,170 @@
+package buf
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errTxTest = errors.New("tx test")
+
+// decodeFrame reads a u16 length followed by that many bytes.
+func decodeFrame(b ByteBuf) (string, error) {
+	var out string
+	err := Transaction(b, func(b ByteBuf) error {
+		n := int(b.ReadUInt16())
+		out = string(b.ReadBytes(n))
+		return nil
+	})
+	return out, err
+}
+
+func TestTransaction_NotEnoughData(t *testing.T) {
+	for _, b := range []ByteBuf{EmptyByteBuf(), NewCompositeByteBuf()} {
+		b.WriteString("xx").Skip(1).MarkReaderIndex().Skip(1)
+		b.WriteBytes([]byte{0, 5, 'h', 'e'})
+
+		_, err := decodeFrame(b)
+		assert.ErrorIs(t, err, ErrInsufficientSize)
+		assert.Equal(t, 2, b.ReaderIndex())
+		assert.Equal(t, 6, b.WriterIndex())
+
+		b.WriteString("llo")
+		got, err := decodeFrame(b)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", got)
+		assert.Equal(t, 0, b.ReadableBytes())
+
+		// The caller's mark survived both attempts.
+		b.ResetReaderIndex()
+		assert.Equal(t, 1, b.ReaderIndex())
+	}
+}
+
+func TestTransaction_ErrorRollsBackReadsWritesAndMarks(t *testing.T) {
+	for _, b := range []ByteBuf{bb("abcdef"), NewCompositeByteBuf(bb("abc"), bb("def"))} {
+		b.Skip(1)
+		err := Transaction(b, func(b ByteBuf) error {
+			b.ReadBytes(3)
+			b.MarkReaderIndex()
+			b.MarkWriterIndex()
+			b.WriteString("appended")
+			return errTxTest
+		})
+		assert.Same(t, errTxTest, err)
+		// Growing the shared DefaultByteBuf dropped the consumed "a", so
+		// compare contents rather than indices.
+		assert.Equal(t, "bcdef", string(b.BytesCopy()))
+		assert.Equal(t, 0, asIndices(b).rmark)
+		assert.Equal(t, 0, asIndices(b).wmark)
+
+		// Writes after a rollback land right after the restored end.
+		b.WriteString("g")
+		assert.Equal(t, "bcdefg", string(b.BytesCopy()))
+	}
+}
+
+// asIndices returns the current marks of a package ByteBuf.
+func asIndices(b ByteBuf) txState {
+	switch b := b.(type) {
+	case *DefaultByteBuf:
+		return txState{rmark: b.prevReaderIndex, wmark: b.prevWriterIndex}
+	case *defaultCompositeByteBuf:
+		return txState{rmark: b.prevReaderIdx, wmark: b.prevWriterIdx}
+	}
+	return txState{}
+}
+
+func TestTransaction_SuccessKeepsProgress(t *testing.T) {
+	b := bb("abcd")
+	assert.NoError(t, Transaction(b, func(b ByteBuf) error {
+		b.Skip(2)
+		b.WriteString("e")
+		return nil
+	}))
+	assert.Equal(t, "cde", string(b.BytesCopy()))
+}
+
+func TestTransaction_OtherPanicPropagates(t *testing.T) {
+	b := bb("abcd")
+	assert.PanicsWithValue(t, errTxTest, func() {
+		Transaction(b, func(b ByteBuf) error {
+			b.Skip(3)
+			panic(errTxTest)
+		})
+	})
+	assert.Equal(t, 0, b.ReaderIndex())
+
+	assert.PanicsWithValue(t, ErrNilObject, func() { Transaction(b, nil) })
+	assert.PanicsWithValue(t, ErrUnsupportedOperation, func() {
+		Transaction(struct{ ByteBuf }{b}, func(ByteBuf) error { return nil })
+	})
+}
+
+func TestTransaction_Nested(t *testing.T) {
+	for _, b := range []ByteBuf{bb("0123456789"), splitComposite([]byte("0123456789"), 3, 7)} {
+		err := Transaction(b, func(b ByteBuf) error {
+			b.Skip(2)
+			inner := Transaction(b, func(b ByteBuf) error {
+				b.Skip(3)
+				return errTxTest
+			})
+			assert.Same(t, errTxTest, inner)
+			assert.Equal(t, 2, b.ReaderIndex())
+
+			assert.NoError(t, Transaction(b, func(b ByteBuf) error {
+				b.Skip(5)
+				return nil
+			}))
+			assert.Equal(t, 7, b.ReaderIndex())
+			b.ReadBytes(4)
+			return nil
+		})
+		assert.ErrorIs(t, err, ErrInsufficientSize)
+		assert.Equal(t, 0, b.ReaderIndex())
+		assert.Equal(t, "0123456789", string(b.BytesCopy()))
+	}
+}
+
+// Growth and Compact inside a transaction keep the bytes a rollback needs,
+// even though they move the indices.
+func TestTransaction_SurvivesCompaction(t *testing.T) {
+	b := AcquireByteBuf(16)
+	defer ReleaseByteBuf(b)
+	b.WriteString("0123456789abcdef").Skip(4)
+	err := Transaction(b, func(b ByteBuf) error {
+		b.Skip(8)
+		b.Compact()
+		b.WriteString("0123456789abcdef0123456789abcdef")
+		return errTxTest
+	})
+	assert.Same(t, errTxTest, err)
+	assert.Equal(t, "456789abcdef", string(b.BytesCopy()))
+
+	c := NewCompositeByteBuf(bb("0123"), bb("4567"))
+	c.Skip(2)
+	err = Transaction(c, func(b ByteBuf) error {
+		b.Skip(4)
+		b.Compact()
+		b.WriteString("tail")
+		return errTxTest
+	})
+	assert.Same(t, errTxTest, err)
+	assert.Equal(t, "234567", string(c.BytesCopy()))
+	c.Compact()
+	assert.Equal(t, 0, c.ReaderIndex())
+	assert.Equal(t, "234567", string(c.BytesCopy()))
+}
+
+func TestTransaction_Ordered(t *testing.T) {
+	o := Order(bb("\x01\x00\x02"), binary.LittleEndian)
+	err := Transaction(o, func(b ByteBuf) error {
+		assert.Equal(t, uint16(1), b.ReadUInt16())
+		b.ReadUInt16()
+		return nil
+	})
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	assert.Equal(t, 0, o.ReaderIndex())
+}
@@ -0,0 +1,235 @@
+package buf
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrVarintOverflow is returned when a varint is longer than the maximum
+// encoding for its width or decodes to a value that does not fit.
+var ErrVarintOverflow = errors.New("varint overflow")
+
+const (
+	maxVarintLen32 = 5
+	maxVarintLen64 = binary.MaxVarintLen64
+)
+
+// VarintCodec is implemented by ByteBufs that read and write LEB128
+// variable-length integers (the protobuf/Kafka wire format). The signed
+// forms use zig-zag encoding. On a decode error the reader index is left
+// untouched: ErrInsufficientSize means the encoding is truncated and more
+// bytes may complete it, ErrVarintOverflow means it can never be valid.
+type VarintCodec interface {
+	WriteVarUInt32(v uint32) ByteBuf
+	WriteVarUInt64(v uint64) ByteBuf
+	WriteVarInt32(v int32) ByteBuf
+	WriteVarInt64(v int64) ByteBuf
+	ReadVarUInt32() (uint32, error)
+	ReadVarUInt64() (uint64, error)
+	ReadVarInt32() (int32, error)
+	ReadVarInt64() (int64, error)
+}
+
+var (
+	_ VarintCodec = (*DefaultByteBuf)(nil)
+	_ VarintCodec = (*defaultCompositeByteBuf)(nil)
+)
+
+// VarintLen returns the number of bytes the unsigned varint encoding of v
+// occupies.
+func VarintLen(v uint64) int {
+	n := 1
+	for v >= 0x80 {
+		v >>= 7
+		n++
+	}
+	return n
+}
+
+// VarintLenZigZag returns the number of bytes the zig-zag varint encoding
+// of v occupies.
+func VarintLenZigZag(v int64) int {
+	return VarintLen(zigZagEncode64(v))
+}
+
+// WriteVarUInt64 writes v to bb as an unsigned varint, through VarintCodec
+// when bb implements it and byte by byte otherwise. Marshal and the code
+// generated by bytebufgen use it, so they accept any ByteBuf.
+func WriteVarUInt64(bb ByteBuf, v uint64) {
+	if vc, ok := bb.(VarintCodec); ok {
+		vc.WriteVarUInt64(v)
+		return
+	}
+	var tmp [maxVarintLen64]byte
+	bb.WriteBytes(tmp[:binary.PutUvarint(tmp[:], v)])
+}
+
+// WriteVarInt64 is WriteVarUInt64 for the zig-zag encoding of v.
+func WriteVarInt64(bb ByteBuf, v int64) {
+	WriteVarUInt64(bb, zigZagEncode64(v))
+}
+
+// ReadVarUInt64 reads an unsigned varint from bb, through VarintCodec when
+// bb implements it and byte by byte otherwise. Unlike VarintCodec, the
+// fallback leaves the bytes it read consumed on error.
+func ReadVarUInt64(bb ByteBuf) (uint64, error) {
+	if vc, ok := bb.(VarintCodec); ok {
+		return vc.ReadVarUInt64()
+	}
+	var tmp [maxVarintLen64]byte
+	for i := range tmp {
+		c, err := bb.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		tmp[i] = c
+		if c < 0x80 {
+			v, _, err := decodeVarUInt(tmp[:i+1], maxVarintLen64, 0x01)
+			return v, err
+		}
+	}
+	return 0, ErrVarintOverflow
+}
+
+// ReadVarInt64 is ReadVarUInt64 for a zig-zag encoded value.
+func ReadVarInt64(bb ByteBuf) (int64, error) {
+	u, err := ReadVarUInt64(bb)
+	if err != nil {
+		return 0, err
+	}
+	return zigZagDecode64(u), nil
+}
+
+func zigZagEncode32(v int32) uint32 { return uint32(v<<1) ^ uint32(v>>31) }
+func zigZagEncode64(v int64) uint64 { return uint64(v<<1) ^ uint64(v>>63) }
+func zigZagDecode32(u uint32) int32 { return int32(u>>1) ^ -int32(u&1) }
+func zigZagDecode64(u uint64) int64 { return int64(u>>1) ^ -int64(u&1) }
+
+// decodeVarUInt decodes a varint from the front of bs, allowing at most
+// maxLen bytes. lastMax bounds the final byte of a maxLen-byte encoding so
+// bits beyond the target width are rejected. It returns the value and the
+// number of bytes consumed.
+func decodeVarUInt(bs []byte, maxLen int, lastMax byte) (uint64, int, error) {
+	var x uint64
+	var s uint
+	for i, c := range bs {
+		if i == maxLen {
+			return 0, 0, ErrVarintOverflow
+		}
+		if c < 0x80 {
+			if i == maxLen-1 && c > lastMax {
+				return 0, 0, ErrVarintOverflow
+			}
+			return x | uint64(c)<<s, i + 1, nil
+		}
+		x |= uint64(c&0x7f) << s
+		s += 7
+	}
+	if len(bs) >= maxLen {
+		return 0, 0, ErrVarintOverflow
+	}
+	return 0, 0, ErrInsufficientSize
+}
+
+// ---------- DefaultByteBuf ----------
+
+func (b *DefaultByteBuf) WriteVarUInt32(v uint32) ByteBuf {
+	return b.WriteVarUInt64(uint64(v))
+}
+
+func (b *DefaultByteBuf) WriteVarUInt64(v uint64) ByteBuf {
+	b.prepare(VarintLen(v))
+	b.writerIndex += binary.PutUvarint(b.buf[b.writerIndex:], v)
+	return b
+}
+
+func (b *DefaultByteBuf) WriteVarInt32(v int32) ByteBuf {
+	return b.WriteVarUInt64(uint64(zigZagEncode32(v)))
+}
+
+func (b *DefaultByteBuf) WriteVarInt64(v int64) ByteBuf {
+	return b.WriteVarUInt64(zigZagEncode64(v))
+}
+
+func (b *DefaultByteBuf) readVarUInt(maxLen int, lastMax byte) (uint64, error) {
+	v, n, err := decodeVarUInt(b.buf[b.readerIndex:b.writerIndex], maxLen, lastMax)
+	if err == ErrInsufficientSize {
+		return 0, b.errInsufficient()
+	}
+	if err != nil {
+		return 0, err
+	}
+	b.readerIndex += n
+	return v, nil
+}
+
+func (b *DefaultByteBuf) ReadVarUInt32() (uint32, error) {
+	v, err := b.readVarUInt(maxVarintLen32, 0x0f)
+	return uint32(v), err
+}
+
+func (b *DefaultByteBuf) ReadVarUInt64() (uint64, error) {
+	return b.readVarUInt(maxVarintLen64, 0x01)
+}
+
+func (b *DefaultByteBuf) ReadVarInt32() (int32, error) {
+	u, err := b.ReadVarUInt32()
+	return zigZagDecode32(u), err
+}
+
+func (b *DefaultByteBuf) ReadVarInt64() (int64, error) {
+	u, err := b.ReadVarUInt64()
+	return zigZagDecode64(u), err
+}
+
+// ---------- defaultCompositeByteBuf ----------
+
+func (c *defaultCompositeByteBuf) WriteVarUInt32(v uint32) ByteBuf {
+	return c.WriteVarUInt64(uint64(v))
+}
+
+func (c *defaultCompositeByteBuf) WriteVarUInt64(v uint64) ByteBuf {
+	var tmp [maxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return c.WriteBytes(tmp[:n])
+}
+
+func (c *defaultCompositeByteBuf) WriteVarInt32(v int32) ByteBuf {
+	return c.WriteVarUInt64(uint64(zigZagEncode32(v)))
+}
+
+func (c *defaultCompositeByteBuf) WriteVarInt64(v int64) ByteBuf {
+	return c.WriteVarUInt64(zigZagEncode64(v))
+}
+
+// readVarUInt gathers at most maxLen bytes across component boundaries into
+// a stack buffer and decodes from there.
+func (c *defaultCompositeByteBuf) readVarUInt(maxLen int, lastMax byte) (uint64, error) {
+	var tmp [maxVarintLen64]byte
+	n := c.copyAt(c.readerIdx, tmp[:maxLen])
+	v, used, err := decodeVarUInt(tmp[:n], maxLen, lastMax)
+	if err != nil {
+		return 0, err
+	}
+	c.readerIdx += used
+	return v, nil
+}
+
+func (c *defaultCompositeByteBuf) ReadVarUInt32() (uint32, error) {
+	v, err := c.readVarUInt(maxVarintLen32, 0x0f)
+	return uint32(v), err
+}
+
+func (c *defaultCompositeByteBuf) ReadVarUInt64() (uint64, error) {
+	return c.readVarUInt(maxVarintLen64, 0x01)
+}
+
+func (c *defaultCompositeByteBuf) ReadVarInt32() (int32, error) {
+	u, err := c.ReadVarUInt32()
+	return zigZagDecode32(u), err
+}
+
+func (c *defaultCompositeByteBuf) ReadVarInt64() (int64, error) {
+	u, err := c.ReadVarUInt64()
+	return zigZagDecode64(u), err
+}
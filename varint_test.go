@@ -0,0 +1,106 @@
+package buf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Unsigned and zig-zag values round-trip and match VarintLen.
+func TestVarint_RoundTrip(t *testing.T) {
+	b := EmptyByteBuf().(*DefaultByteBuf)
+	u64s := []uint64{0, 1, 127, 128, 300, 1 << 35, math.MaxUint64}
+	for _, v := range u64s {
+		before := b.WriterIndex()
+		b.WriteVarUInt64(v)
+		assert.Equal(t, VarintLen(v), b.WriterIndex()-before)
+	}
+	for _, v := range u64s {
+		got, err := b.ReadVarUInt64()
+		assert.NoError(t, err)
+		assert.Equal(t, v, got)
+	}
+
+	i32s := []int32{0, -1, 1, -64, 64, math.MinInt32, math.MaxInt32}
+	for _, v := range i32s {
+		b.WriteVarInt32(v)
+	}
+	for _, v := range i32s {
+		got, err := b.ReadVarInt32()
+		assert.NoError(t, err)
+		assert.Equal(t, v, got)
+	}
+
+	b.WriteVarInt64(math.MinInt64)
+	b.WriteVarUInt32(math.MaxUint32)
+	i64, err := b.ReadVarInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(math.MinInt64), i64)
+	u32, err := b.ReadVarUInt32()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(math.MaxUint32), u32)
+}
+
+// Zig-zag maps small magnitudes to small encodings.
+func TestVarint_ZigZagLen(t *testing.T) {
+	assert.Equal(t, 1, VarintLenZigZag(-1))
+	assert.Equal(t, 1, VarintLenZigZag(63))
+	assert.Equal(t, 2, VarintLenZigZag(-65))
+	assert.Equal(t, 10, VarintLenZigZag(math.MinInt64))
+	assert.Equal(t, []byte{0x03}, EmptyByteBuf().(*DefaultByteBuf).WriteVarInt64(-2).Bytes())
+}
+
+// A truncated encoding reports ErrInsufficientSize and keeps readerIndex.
+func TestVarint_Truncated(t *testing.T) {
+	b := NewByteBuf([]byte{0xAC}).(*DefaultByteBuf)
+	_, err := b.ReadVarUInt64()
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+	assert.Equal(t, 0, b.ReaderIndex())
+
+	b.WriteByte(0x02)
+	v, err := b.ReadVarUInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(300), v)
+}
+
+// Overlong and overflowing encodings are rejected instead of wrapping.
+func TestVarint_Overflow(t *testing.T) {
+	overlong := NewByteBuf([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x00}).(*DefaultByteBuf)
+	_, err := overlong.ReadVarUInt64()
+	assert.ErrorIs(t, err, ErrVarintOverflow)
+	assert.Equal(t, 0, overlong.ReaderIndex())
+
+	tooBig64 := NewByteBuf([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x02}).(*DefaultByteBuf)
+	_, err = tooBig64.ReadVarUInt64()
+	assert.ErrorIs(t, err, ErrVarintOverflow)
+
+	tooBig32 := NewByteBuf([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x1F}).(*DefaultByteBuf)
+	_, err = tooBig32.ReadVarUInt32()
+	assert.ErrorIs(t, err, ErrVarintOverflow)
+
+	max32 := NewByteBuf([]byte{0xFF, 0xFF, 0xFF, 0xFF, 0x0F}).(*DefaultByteBuf)
+	v, err := max32.ReadVarUInt32()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(math.MaxUint32), v)
+}
+
+// Varints split across components decode without consolidating.
+func TestVarint_Composite_CrossBoundary(t *testing.T) {
+	c := asDefault(NewCompositeByteBuf(bbBytes([]byte{0xAC}), bbBytes([]byte{0x02, 0xFF}), bbBytes([]byte{0xFF, 0x03})))
+	v, err := c.ReadVarUInt32()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(300), v)
+	v, err = c.ReadVarUInt32()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0xFFFF), v)
+	assert.Equal(t, 3, len(c.components))
+
+	c.WriteVarInt64(-12345)
+	i, err := c.ReadVarInt64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-12345), i)
+
+	_, err = c.ReadVarUInt64()
+	assert.ErrorIs(t, err, ErrInsufficientSize)
+}